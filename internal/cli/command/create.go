@@ -1,16 +1,21 @@
 package command
 
 import (
+	"bytes"
 	"errors"
+	"flag"
 	"fmt"
-	"html/template"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/EvgenyRomanov/sql-migrator/internal/cli/config"
 	"github.com/EvgenyRomanov/sql-migrator/internal/logger"
+	"github.com/EvgenyRomanov/sql-migrator/pkg/core"
 )
 
 var ErrMissingName = errors.New("no migration name was set")
@@ -18,29 +23,69 @@ var ErrMissingName = errors.New("no migration name was set")
 type Create struct {
 	Cfg    *config.MigratorConf
 	Logger *logger.Logger
+
+	// Name of template from templates dir or path to template file.
+	template string
+
+	// Generated statements of migration (used by diff).
+	up   string
+	down string
+}
+
+// TemplateData contains variables available in migration templates.
+type TemplateData struct {
+	// Name of migration as it was passed to command.
+	Name string
+
+	// Version of migration.
+	Version string
+
+	// Author of migration from config or $USER.
+	Author string
+
+	// Date of creation (UTC).
+	Date string
+
+	// Generated Up and Down statements, empty if migration is not generated.
+	Up   string
+	Down string
 }
 
 func (c *Create) Run(args []string) error {
-	if len(args) == 0 {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&c.template, "template", c.Cfg.Template, "Name of template or path to template file")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
 		return ErrMissingName
 	}
 
-	return c.create(args[0])
+	return c.create(fs.Arg(0))
 }
 
 func (c *Create) create(name string) error {
 	// Define new version of migration file.
-	version := time.Now().UTC().UnixMilli()
+	version, err := c.nextVersion()
+	if err != nil {
+		return err
+	}
 
 	// Define full filename.
 	fullName := fmt.Sprintf("%v_%v", version, c.snakeCase(name))
 	filename := fmt.Sprintf("%v.sql", fullName)
 
 	// Define template.
-	tmpl := sqlMigrationTemplate
+	tmpl, err := c.loadTemplate()
+	if err != nil {
+		return err
+	}
 
 	// Try to create path.
-	err := os.MkdirAll(c.Cfg.Dir, 0o755)
+	err = os.MkdirAll(c.Cfg.Dir, 0o755)
 	if err != nil {
 		return fmt.Errorf("failed to create migration folder: %w", err)
 	}
@@ -51,21 +96,92 @@ func (c *Create) create(name string) error {
 		return fmt.Errorf("failed to create migration file: %w", err)
 	}
 
-	// Try to create file.
-	f, err := os.Create(path)
+	data := TemplateData{
+		Name:    name,
+		Version: version,
+		Author:  c.author(),
+		Date:    time.Now().UTC().Format("2006-01-02 15:04:05"),
+		Up:      c.up,
+		Down:    c.down,
+	}
+
+	// Render template before creating file, so failed template doesn't leave partial migration.
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, data); err != nil {
+		return fmt.Errorf("failed to execute tmpl: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
 	if err != nil {
 		return fmt.Errorf("failed to create migration file2: %w", err)
 	}
 	defer f.Close()
 
-	if err := tmpl.Execute(f, struct{}{}); err != nil {
-		return fmt.Errorf("failed to execute tmpl: %w", err)
+	if _, err := f.Write(content.Bytes()); err != nil {
+		return fmt.Errorf("failed to write migration file: %w", err)
 	}
 
 	c.Logger.Info("Success create new migration %s", filename)
 	return nil
 }
 
+// Load user template by name (from templates dir) or by path, default template is used if none is set.
+func (c *Create) loadTemplate() (*template.Template, error) {
+	if c.template == "" {
+		return sqlMigrationTemplate, nil
+	}
+
+	candidates := []string{c.template}
+	if c.Cfg.TemplatesDir != "" {
+		for _, ext := range []string{"", ".sql", ".tmpl"} {
+			candidates = append(candidates, filepath.Join(c.Cfg.TemplatesDir, c.template+ext))
+		}
+	}
+
+	for _, path := range candidates {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		tmpl, err := template.New(filepath.Base(path)).ParseFiles(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", path, err)
+		}
+
+		return tmpl, nil
+	}
+
+	return nil, fmt.Errorf("template %q not found", c.template)
+}
+
+func (c *Create) author() string {
+	if c.Cfg.Author != "" {
+		return c.Cfg.Author
+	}
+
+	return os.Getenv("USER")
+}
+
+// Timestamp version by default or sequential one, following the last version in dir.
+func (c *Create) nextVersion() (string, error) {
+	if c.Cfg.Numbering != config.NumberingSequential {
+		return strconv.FormatInt(time.Now().UTC().UnixMilli(), 10), nil
+	}
+
+	// No migrations yet.
+	if _, err := os.Stat(c.Cfg.Dir); os.IsNotExist(err) {
+		return fmt.Sprintf(core.SequentialVersionFormat, 1), nil
+	}
+
+	migrations, err := core.Discover(c.Cfg.Dir, c.Cfg.FilePattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to define next version: %w", err)
+	}
+
+	return fmt.Sprintf(core.SequentialVersionFormat, core.NextSequentialVersion(migrations)), nil
+}
+
 func (c *Create) snakeCase(s string) string {
 	var b strings.Builder
 
@@ -98,8 +214,8 @@ func (c *Create) snakeCase(s string) string {
 }
 
 var sqlMigrationTemplate = template.Must(template.New("gomigrator.sql-migration").Parse(`-- +gomigrator Up
-SELECT 'up SQL query';
+{{ if .Up }}{{ .Up }}{{ else }}SELECT 'up SQL query';{{ end }}
 
 -- +gomigrator Down
-SELECT 'down SQL query';
+{{ if .Down }}{{ .Down }}{{ else }}SELECT 'down SQL query';{{ end }}
 `))
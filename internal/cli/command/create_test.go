@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -65,3 +66,130 @@ func TestCreate(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateSequential(t *testing.T) {
+	cmd := &Create{
+		Cfg: &config.MigratorConf{
+			Dir:       filepath.Join(t.TempDir(), "migrations"),
+			Numbering: config.NumberingSequential,
+		},
+		Logger: logger.New("DEBUG", io.Discard),
+	}
+
+	for _, name := range []string{"first", "second"} {
+		if err := cmd.create(name); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	files, err := os.ReadDir(cmd.Cfg.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"0001_first.sql", "0002_second.sql"}
+	for i, f := range files {
+		if f.Name() != expected[i] {
+			t.Errorf("Error: Expected: %v, but received: %v", expected[i], f.Name())
+		}
+	}
+}
+
+func TestCreateFromTemplate(t *testing.T) {
+	templatesDir := t.TempDir()
+	tmpl := "-- Author: {{ .Author }}, version {{ .Version }}\n" +
+		"-- +gomigrator Up\n" +
+		"ALTER TABLE {{ .Name }} ADD COLUMN updated_at timestamp DEFAULT now() CHECK (updated_at > '2000-01-01');\n"
+	if err := os.WriteFile(filepath.Join(templatesDir, "add_column.sql"), []byte(tmpl), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &Create{
+		Cfg: &config.MigratorConf{
+			Dir:          t.TempDir(),
+			TemplatesDir: templatesDir,
+			Numbering:    config.NumberingSequential,
+			Author:       "tester",
+		},
+		Logger: logger.New("DEBUG", io.Discard),
+	}
+
+	if err := cmd.Run([]string{"-template", "add_column", "users"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(cmd.Cfg.Dir, "0001_users.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "-- Author: tester, version 0001\n" +
+		"-- +gomigrator Up\n" +
+		"ALTER TABLE users ADD COLUMN updated_at timestamp DEFAULT now() CHECK (updated_at > '2000-01-01');\n"
+	if string(content) != expected {
+		t.Errorf("Error: Expected: %q, but received: %q", expected, string(content))
+	}
+
+	if err := cmd.Run([]string{"-template", "missing", "users"}); err == nil {
+		t.Error("Error: Expected error for missing template")
+	}
+}
+
+func TestCreateGenerated(t *testing.T) {
+	cmd := &Create{
+		Cfg: &config.MigratorConf{
+			Dir:       t.TempDir(),
+			Numbering: config.NumberingSequential,
+		},
+		Logger: logger.New("DEBUG", io.Discard),
+		up:     "ALTER TABLE public.users ADD COLUMN email text;",
+		down:   "ALTER TABLE public.users DROP COLUMN email;",
+	}
+
+	if err := cmd.create("add_email"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(cmd.Cfg.Dir, "0001_add_email.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "-- +gomigrator Up\n" +
+		"ALTER TABLE public.users ADD COLUMN email text;\n\n" +
+		"-- +gomigrator Down\n" +
+		"ALTER TABLE public.users DROP COLUMN email;\n"
+	if string(content) != expected {
+		t.Errorf("Error: Expected: %q, but received: %q", expected, string(content))
+	}
+}
+
+func TestCreateFailedTemplate(t *testing.T) {
+	templatesDir := t.TempDir()
+	tmpl := "-- +gomigrator Up\nALTER TABLE {{ .Name }} ADD COLUMN {{ .Column.Name }} text;\n"
+	if err := os.WriteFile(filepath.Join(templatesDir, "broken.sql"), []byte(tmpl), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &Create{
+		Cfg: &config.MigratorConf{
+			Dir:          t.TempDir(),
+			TemplatesDir: templatesDir,
+			Numbering:    config.NumberingSequential,
+		},
+		Logger: logger.New("DEBUG", io.Discard),
+	}
+
+	if err := cmd.Run([]string{"-template", "broken", "users"}); err == nil {
+		t.Fatal("Error: Expected error for failed template")
+	}
+
+	files, err := os.ReadDir(cmd.Cfg.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 0 {
+		t.Errorf("Error: Expected no files, but received: %v", files[0].Name())
+	}
+}
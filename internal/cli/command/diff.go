@@ -0,0 +1,70 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/EvgenyRomanov/sql-migrator/internal/cli/config"
+	"github.com/EvgenyRomanov/sql-migrator/internal/logger"
+	"github.com/EvgenyRomanov/sql-migrator/pkg/core"
+)
+
+var (
+	ErrDiffUsage     = errors.New("usage: diff -from <dsn|schema> -to <dsn|schema> create <name>")
+	ErrNoDifferences = errors.New("schemas are equal, no migration created")
+)
+
+type Diff struct {
+	Cfg    *config.MigratorConf
+	Logger *logger.Logger
+}
+
+func (c *Diff) Run(args []string) error {
+	var from, to string
+
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&from, "from", "", "DSN of database or name of schema with current state")
+	fs.StringVar(&to, "to", "", "DSN of database or name of schema with desired state")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if from == "" || to == "" || fs.NArg() != 2 || fs.Arg(0) != "create" {
+		return ErrDiffUsage
+	}
+
+	// Database from config is needed only to compare its schemas.
+	if c.Cfg.DSN == "" && (!strings.Contains(from, "://") || !strings.Contains(to, "://")) {
+		return fmt.Errorf("%w: dsn is required to compare schemas of database", config.ErrInvalidConfig)
+	}
+
+	up, down, err := core.Diff(
+		from,
+		to,
+		core.WithDSN(c.Cfg.DSN),
+		core.WithTableName(c.Cfg.TableName),
+		core.WithSeedsTableName(c.Cfg.SeedsTableName),
+		core.WithSchema(c.Cfg.Schema),
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(up) == 0 {
+		return ErrNoDifferences
+	}
+
+	create := &Create{
+		Cfg:    c.Cfg,
+		Logger: c.Logger,
+		up:     strings.Join(up, "\n"),
+		down:   strings.Join(down, "\n"),
+	}
+
+	return create.create(fs.Arg(1))
+}
@@ -0,0 +1,58 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/EvgenyRomanov/sql-migrator/internal/logger"
+	"github.com/EvgenyRomanov/sql-migrator/pkg/core"
+	"github.com/EvgenyRomanov/sql-migrator/pkg/schema"
+)
+
+type Drift struct {
+	Migrator *core.Migrate
+	Logger   *logger.Logger
+
+	// DSN of scratch database or name of scratch schema from config.
+	Scratch string
+}
+
+func (c *Drift) Run(args []string) error {
+	var inSchema bool
+	scratch := c.Scratch
+
+	fs := flag.NewFlagSet("drift", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&scratch, "scratch", scratch, "DSN of scratch database or name of scratch schema")
+	fs.BoolVar(&inSchema, "in-schema", false, "Apply migrations to scratch schema of target database")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var diffs []schema.Difference
+	var err error
+
+	// Scratch schema changes target database, so it is used only on explicit request.
+	if inSchema {
+		diffs, err = c.Migrator.DriftInSchema(scratch)
+	} else {
+		diffs, err = c.Migrator.Drift(scratch)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, diff := range diffs {
+		c.Logger.Warning("%s", diff.String())
+	}
+
+	if len(diffs) > 0 {
+		return fmt.Errorf("%w: %d differences", core.ErrDriftDetected, len(diffs))
+	}
+
+	c.Logger.Info("No schema drift detected")
+
+	return nil
+}
@@ -0,0 +1,17 @@
+package command
+
+import (
+	"github.com/EvgenyRomanov/sql-migrator/internal/logger"
+	"github.com/EvgenyRomanov/sql-migrator/pkg/core"
+)
+
+type Fix struct {
+	Migrator *core.Migrate
+	Logger   *logger.Logger
+}
+
+func (c *Fix) Run(_ []string) error {
+	_, err := c.Migrator.Fix()
+
+	return err
+}
@@ -0,0 +1,36 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/EvgenyRomanov/sql-migrator/internal/cli/config"
+	"github.com/EvgenyRomanov/sql-migrator/internal/lint"
+	"github.com/EvgenyRomanov/sql-migrator/internal/logger"
+)
+
+var ErrLintFailed = errors.New("problems found in migration files")
+
+type Lint struct {
+	Cfg    *config.MigratorConf
+	Logger *logger.Logger
+}
+
+func (c *Lint) Run(_ []string) error {
+	issues, err := lint.Dir(c.Cfg.Dir, c.Cfg.FilePattern)
+	if err != nil {
+		return fmt.Errorf("can't lint migrations: %w", err)
+	}
+
+	for _, issue := range issues {
+		c.Logger.Warning("%s", issue.String())
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("%w: %d", ErrLintFailed, len(issues))
+	}
+
+	c.Logger.Info("No problems found in %s", c.Cfg.Dir)
+
+	return nil
+}
@@ -0,0 +1,17 @@
+package command
+
+import (
+	"github.com/EvgenyRomanov/sql-migrator/internal/logger"
+	"github.com/EvgenyRomanov/sql-migrator/pkg/core"
+)
+
+type Repair struct {
+	Migrator *core.Migrate
+	Logger   *logger.Logger
+}
+
+func (c *Repair) Run(_ []string) error {
+	_, err := c.Migrator.Repair()
+
+	return err
+}
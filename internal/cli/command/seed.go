@@ -0,0 +1,20 @@
+package command
+
+import (
+	"github.com/EvgenyRomanov/sql-migrator/internal/logger"
+	"github.com/EvgenyRomanov/sql-migrator/pkg/core"
+)
+
+type Seed struct {
+	Seeder *core.Seeder
+	Logger *logger.Logger
+}
+
+func (c *Seed) Run(args []string) error {
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	return c.Seeder.Seed(name)
+}
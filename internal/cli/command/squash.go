@@ -0,0 +1,48 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/EvgenyRomanov/sql-migrator/internal/cli/config"
+	"github.com/EvgenyRomanov/sql-migrator/internal/logger"
+	"github.com/EvgenyRomanov/sql-migrator/pkg/core"
+)
+
+var ErrMissingUntil = errors.New("no version to squash until was set, use '-until <version>'")
+
+type Squash struct {
+	Cfg    *config.MigratorConf
+	Logger *logger.Logger
+}
+
+func (c *Squash) Run(args []string) error {
+	var (
+		until   int64
+		archive string
+	)
+
+	fs := flag.NewFlagSet("squash", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Int64Var(&until, "until", 0, "Squash migrations with versions up to this one")
+	fs.StringVar(&archive, "archive", "", "Folder to move squashed migrations to (they are removed by default)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if until <= 0 {
+		return ErrMissingUntil
+	}
+
+	squashed, err := core.Squash(c.Cfg.Dir, c.Cfg.FilePattern, until, archive)
+	if err != nil {
+		return fmt.Errorf("can't squash migrations: %w", err)
+	}
+
+	c.Logger.Info("%d migrations squashed into %s", len(squashed.Sources), squashed.File)
+
+	return nil
+}
@@ -22,11 +22,23 @@ func (c *Status) Run(_ []string) error {
 
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
-	t.AppendHeader(table.Row{"#", "Version", "Name", "Applied At"})
+	t.AppendHeader(table.Row{"#", "Version", "Name", "Status", "Updated At", "Error"})
 
 	for i, migration := range migrations {
+		var version any = migration.Version
+		if migration.Repeatable {
+			version = "R"
+		}
+
 		t.AppendRows([]table.Row{
-			{i + 1, migration.Version, migration.Source, migration.AppliedAt.Format("2006-01-02 15:04:05")},
+			{
+				i + 1,
+				version,
+				migration.Source,
+				migration.Status,
+				migration.AppliedAt.Format("2006-01-02 15:04:05"),
+				migration.Error,
+			},
 		})
 	}
 
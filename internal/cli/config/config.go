@@ -1,10 +1,16 @@
 package config
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database"
 	"github.com/spf13/viper"
 )
 
@@ -14,84 +20,410 @@ type Config struct {
 }
 
 type MigratorConf struct {
-	DSN       string `mapstructure:"dsn"`
-	Dir       string `mapstructure:"dir"`
-	TableName string `mapstructure:"table_name"`
+	DSN            string         `mapstructure:"dsn"`
+	Connection     ConnectionConf `mapstructure:",squash"`
+	Dir            string         `mapstructure:"dir"`
+	TableName      string         `mapstructure:"table_name"`
+	FilePattern    string         `mapstructure:"file_pattern"`
+	Numbering      string         `mapstructure:"numbering"`
+	Template       string         `mapstructure:"template"`
+	TemplatesDir   string         `mapstructure:"templates_dir"`
+	Author         string         `mapstructure:"author"`
+	Schema         string         `mapstructure:"schema"`
+	SchemaFile     string         `mapstructure:"schema_file"`
+	Scratch        string         `mapstructure:"scratch"`
+	SeedsDir       string         `mapstructure:"seeds_dir"`
+	SeedsTableName string         `mapstructure:"seeds_table_name"`
+	Env            string         `mapstructure:"env"`
+	Safety         SafetyConf     `mapstructure:"safety"`
+
+	// Values of placeholders in migrations, names are case-insensitive.
+	Variables       map[string]string `mapstructure:"variables"`
+	StrictVariables bool              `mapstructure:"strict_variables"`
+}
+
+type SafetyConf struct {
+	// Forbid commands which rollback migrations (down, redo).
+	DisableDown bool `mapstructure:"disable_down"`
+
+	// Ask for confirmation before commands which change DB.
+	Confirm bool `mapstructure:"confirm"`
 }
 
 type LoggerConf struct {
 	Level string `mapstructure:"level"`
 }
 
-var (
-	configFile string
-	dsn        string
-	dir        string
-	tableName  string
+const (
+	DefaultDir            = "./migrations"
+	DefaultTableName      = "migrations"
+	DefaultSeedsDir       = "./seeds"
+	DefaultSeedsTableName = "seeds"
+	DefaultLogLevel       = "INFO"
+
+	// Numbering of new migrations.
+	NumberingTimestamp  = "timestamp"
+	NumberingSequential = "sequential"
+
+	// EnvVariable is environment variable to select environment, if flag is not set.
+	EnvVariable = "GOMIGRATOR_ENV"
+
+	// VariablePrefix is prefix of environment variables, which set values of placeholders
+	// (GOMIGRATOR_VAR_APP_USER sets variable app_user).
+	VariablePrefix = "GOMIGRATOR_VAR_"
 )
 
-func initFlag() {
-	flag.StringVar(&configFile, "config", "", "Path to configuration file")
-	flag.StringVar(&dsn, "dsn", "", "Database string connection")
-	flag.StringVar(&dir, "dir", "./migrations", "Path to migration folder")
-	flag.StringVar(&tableName, "tableName", "migrations", "Name of migrations table")
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// Loader builds configuration from (in order of precedence, the last wins):
+// defaults, config file (with selected environment), environment variables and flags.
+type Loader struct {
+	// Command line arguments without program name.
+	Args []string
+
+	// LookupEnv is used to read environment variables (os.LookupEnv by default).
+	LookupEnv func(key string) (string, bool)
+
+	// Environ is used to list environment variables in "key=value" form (os.Environ by default).
+	Environ func() []string
+}
+
+// Values of command line flags.
+type flagValues struct {
+	configFile     string
+	dsn            string
+	dir            string
+	tableName      string
+	schema         string
+	seedsDir       string
+	seedsTableName string
+	env            string
+	logLevel       string
+}
+
+// Load returns validated configuration and the rest of arguments (command with its arguments).
+func Load(args []string) (*Config, []string, error) {
+	loader := &Loader{Args: args}
 
-	flag.Parse()
+	return loader.Load()
 }
 
-func NewConfig() *Config {
-	initFlag()
+// Load returns validated configuration and the rest of arguments (command with its arguments).
+func (l *Loader) Load() (*Config, []string, error) {
+	flags, fs, err := l.parseFlags()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config := defaultConfig()
+
+	env := flags.env
+	if env == "" {
+		env, _ = l.lookupEnv(EnvVariable)
+	}
+
+	if flags.configFile != "" {
+		if err := l.readFile(flags.configFile, env, config); err != nil {
+			return nil, nil, err
+		}
+	}
 
+	if env != "" {
+		config.Migrator.Env = env
+	}
+
+	if err := l.applyEnvVariables(config); err != nil {
+		return nil, nil, err
+	}
+	applyFlags(fs, flags, config)
+
+	// Assemble DSN from parts or add connection parameters to it.
+	if config.Migrator.DSN == "" && config.Migrator.Connection.Host != "" {
+		dsn, err := config.Migrator.Connection.BuildDSN()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		config.Migrator.DSN = dsn
+	} else if config.Migrator.DSN != "" {
+		dsn, err := config.Migrator.Connection.ApplyParams(config.Migrator.DSN)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		config.Migrator.DSN = dsn
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	return config, fs.Args(), nil
+}
+
+// Validate checks that all required settings are set.
+// Connection settings are checked by ValidateConnection, as not every command needs DB.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Migrator.Dir == "" {
+		errs = append(errs, fmt.Errorf("%w: dir is not set", ErrInvalidConfig))
+	}
+
+	if c.Migrator.TableName == "" {
+		errs = append(errs, fmt.Errorf("%w: table_name is not set", ErrInvalidConfig))
+	}
+
+	if c.Migrator.Numbering != NumberingTimestamp && c.Migrator.Numbering != NumberingSequential {
+		errs = append(errs, fmt.Errorf(
+			"%w: numbering should be %q or %q", ErrInvalidConfig, NumberingTimestamp, NumberingSequential,
+		))
+	}
+
+	if c.Migrator.SeedsTableName == "" {
+		errs = append(errs, fmt.Errorf("%w: seeds_table_name is not set", ErrInvalidConfig))
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateConnection checks that DB connection is configured.
+func (c *Config) ValidateConnection() error {
+	if c.Migrator.DSN == "" {
+		return fmt.Errorf("%w: neither dsn nor host is set", ErrInvalidConfig)
+	}
+
+	return nil
+}
+
+// Secrets returns values, which should be hidden in logs.
+func (c MigratorConf) Secrets() []string {
+	secrets := c.Connection.Secrets()
+	if password := database.Password(c.DSN); password != "" {
+		secrets = append(secrets, password, url.PathEscape(password), url.QueryEscape(password))
+	}
+
+	return secrets
+}
+
+// PlaceholderValues returns values of placeholders in migrations:
+// variables with built-in "schema" and "env", unless they are set explicitly.
+func (c MigratorConf) PlaceholderValues() map[string]string {
+	values := make(map[string]string)
+	if c.Schema != "" {
+		values["schema"] = c.Schema
+	}
+	if c.Env != "" {
+		values["env"] = c.Env
+	}
+
+	for name, value := range c.Variables {
+		values[strings.ToLower(name)] = value
+	}
+
+	return values
+}
+
+// QualifiedSeedsTableName returns name of seeds table with schema.
+func (c MigratorConf) QualifiedSeedsTableName() string {
+	return qualify(c.Schema, c.SeedsTableName)
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Migrator: MigratorConf{
+			Dir:            DefaultDir,
+			TableName:      DefaultTableName,
+			Numbering:      NumberingTimestamp,
+			SeedsDir:       DefaultSeedsDir,
+			SeedsTableName: DefaultSeedsTableName,
+		},
+		Logger: LoggerConf{
+			Level: DefaultLogLevel,
+		},
+	}
+}
+
+func (l *Loader) parseFlags() (*flagValues, *flag.FlagSet, error) {
+	values := &flagValues{}
+
+	fs := flag.NewFlagSet("gomigrator", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	fs.StringVar(&values.configFile, "config", "", "Path to configuration file")
+	fs.StringVar(&values.dsn, "dsn", "", "Database string connection")
+	fs.StringVar(&values.dir, "dir", "", "Path to migration folder")
+	fs.StringVar(&values.tableName, "tableName", "", "Name of migrations table")
+	fs.StringVar(&values.schema, "schema", "", "Schema for migrations and seeds tables")
+	fs.StringVar(&values.seedsDir, "seedsDir", "", "Path to seeds folder")
+	fs.StringVar(&values.seedsTableName, "seedsTableName", "", "Name of seeds table")
+	fs.StringVar(&values.env, "env", "", "Name of environment from config file")
+	fs.StringVar(&values.logLevel, "logLevel", "", "Level of logging")
+
+	if err := fs.Parse(l.Args); err != nil {
+		return nil, nil, err
+	}
+
+	return values, fs, nil
+}
+
+// Read config file and override shared settings by settings of selected environment.
+func (l *Loader) readFile(path string, env string, config *Config) error {
 	v := viper.New()
-	v.AutomaticEnv()
-	v.SetConfigFile(configFile)
+	v.SetConfigFile(path)
+
+	if err := v.ReadInConfig(); err != nil {
+		return fmt.Errorf("couldn't load config: %w", err)
+	}
+
+	if err := applyEnvironment(v, env); err != nil {
+		return err
+	}
+
+	// Support to set ENV variables in file.
+	for _, k := range v.AllKeys() {
+		v.Set(k, os.Expand(v.GetString(k), l.getEnv))
+	}
+
+	if err := v.Unmarshal(config); err != nil {
+		return fmt.Errorf("couldn't read config: %w", err)
+	}
+
+	return nil
+}
 
-	var config Config
+// Override settings by GOMIGRATOR_* environment variables.
+func (l *Loader) applyEnvVariables(config *Config) error {
+	variables := map[string]*string{
+		"GOMIGRATOR_DSN":               &config.Migrator.DSN,
+		"GOMIGRATOR_DIR":               &config.Migrator.Dir,
+		"GOMIGRATOR_TABLE_NAME":        &config.Migrator.TableName,
+		"GOMIGRATOR_FILE_PATTERN":      &config.Migrator.FilePattern,
+		"GOMIGRATOR_NUMBERING":         &config.Migrator.Numbering,
+		"GOMIGRATOR_TEMPLATES_DIR":     &config.Migrator.TemplatesDir,
+		"GOMIGRATOR_AUTHOR":            &config.Migrator.Author,
+		"GOMIGRATOR_SCHEMA":            &config.Migrator.Schema,
+		"GOMIGRATOR_SCHEMA_FILE":       &config.Migrator.SchemaFile,
+		"GOMIGRATOR_SCRATCH":           &config.Migrator.Scratch,
+		"GOMIGRATOR_SEEDS_DIR":         &config.Migrator.SeedsDir,
+		"GOMIGRATOR_SEEDS_TABLE_NAME":  &config.Migrator.SeedsTableName,
+		"GOMIGRATOR_LOG_LEVEL":         &config.Logger.Level,
+		"GOMIGRATOR_HOST":              &config.Migrator.Connection.Host,
+		"GOMIGRATOR_PORT":              &config.Migrator.Connection.Port,
+		"GOMIGRATOR_USER":              &config.Migrator.Connection.User,
+		"GOMIGRATOR_PASSWORD":          &config.Migrator.Connection.Password,
+		"GOMIGRATOR_PASSWORD_FILE":     &config.Migrator.Connection.PasswordFile,
+		"GOMIGRATOR_DATABASE":          &config.Migrator.Connection.Database,
+		"GOMIGRATOR_SSLMODE":           &config.Migrator.Connection.SSLMode,
+		"GOMIGRATOR_SSLROOTCERT":       &config.Migrator.Connection.SSLRootCert,
+		"GOMIGRATOR_SSLCERT":           &config.Migrator.Connection.SSLCert,
+		"GOMIGRATOR_SSLKEY":            &config.Migrator.Connection.SSLKey,
+		"GOMIGRATOR_STATEMENT_TIMEOUT": &config.Migrator.Connection.StatementTimeout,
+		"GOMIGRATOR_LOCK_TIMEOUT":      &config.Migrator.Connection.LockTimeout,
+		"GOMIGRATOR_APPLICATION_NAME":  &config.Migrator.Connection.ApplicationName,
+		"PGPASSFILE":                   &config.Migrator.Connection.PassFile,
+	}
 
-	// Read from file.
-	if configFile != "" {
-		if err := v.ReadInConfig(); err != nil {
-			fmt.Printf("[ERROR] Couldn't load config: %s\n", err)
-			os.Exit(1)
+	for name, target := range variables {
+		if value, ok := l.lookupEnv(name); ok && value != "" {
+			*target = value
 		}
+	}
 
-		// Support to set ENV variables in file.
-		for _, k := range v.AllKeys() {
-			val := v.GetString(k)
-			v.Set(k, os.ExpandEnv(val))
+	if value, ok := l.lookupEnv("GOMIGRATOR_STRICT_VARIABLES"); ok && value != "" {
+		strict, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%w: GOMIGRATOR_STRICT_VARIABLES should be boolean, got %q", ErrInvalidConfig, value)
 		}
 
-		if err := v.Unmarshal(&config); err != nil {
-			fmt.Printf("[ERROR] Couldn't read config: %s\n", err)
+		config.Migrator.StrictVariables = strict
+	}
+
+	for _, env := range l.environ() {
+		key, value, _ := strings.Cut(env, "=")
+		if !strings.HasPrefix(key, VariablePrefix) || key == VariablePrefix {
+			continue
 		}
-	} else {
-		// Use values from flags.
-		config.Migrator = MigratorConf{
-			DSN:       dsn,
-			Dir:       dir,
-			TableName: tableName,
+
+		if config.Migrator.Variables == nil {
+			config.Migrator.Variables = make(map[string]string)
 		}
+		config.Migrator.Variables[strings.ToLower(strings.TrimPrefix(key, VariablePrefix))] = value
+	}
 
-		// Default config for logger.
-		config.Logger = LoggerConf{
-			Level: "INFO",
+	return nil
+}
+
+// Override settings by flags, which were set explicitly.
+func applyFlags(fs *flag.FlagSet, values *flagValues, config *Config) {
+	targets := map[string]struct {
+		value  string
+		target *string
+	}{
+		"dsn":            {values.dsn, &config.Migrator.DSN},
+		"dir":            {values.dir, &config.Migrator.Dir},
+		"tableName":      {values.tableName, &config.Migrator.TableName},
+		"schema":         {values.schema, &config.Migrator.Schema},
+		"seedsDir":       {values.seedsDir, &config.Migrator.SeedsDir},
+		"seedsTableName": {values.seedsTableName, &config.Migrator.SeedsTableName},
+		"logLevel":       {values.logLevel, &config.Logger.Level},
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		if t, ok := targets[f.Name]; ok {
+			*t.target = t.value
 		}
+	})
+}
+
+func (l *Loader) lookupEnv(key string) (string, bool) {
+	if l.LookupEnv == nil {
+		return os.LookupEnv(key)
 	}
 
-	if config.Migrator.DSN == "" {
-		fmt.Printf("[ERROR] Wrong configuration of app. Cannot get a DSN setting!\n")
-		os.Exit(1)
+	return l.LookupEnv(key)
+}
+
+func (l *Loader) environ() []string {
+	if l.Environ == nil {
+		return os.Environ()
 	}
 
-	if config.Migrator.Dir == "" {
-		fmt.Printf("[ERROR] Wrong configuration of app. Cannot get a Dir setting!\n")
-		os.Exit(1)
+	return l.Environ()
+}
+
+func (l *Loader) getEnv(key string) string {
+	value, _ := l.lookupEnv(key)
+
+	return value
+}
+
+func qualify(schema string, tableName string) string {
+	if schema == "" {
+		return tableName
 	}
 
-	if config.Migrator.TableName == "" {
-		fmt.Printf("[ERROR] Wrong configuration of app. Cannot get a TableName setting!\n")
-		os.Exit(1)
+	return schema + "." + tableName
+}
+
+// Copy settings of environment "environments.<env>" over "migrator" section.
+func applyEnvironment(v *viper.Viper, env string) error {
+	if env == "" {
+		return nil
+	}
+
+	// Viper keys are case insensitive.
+	key := strings.ToLower(env)
+	if _, ok := v.GetStringMap("environments")[key]; !ok {
+		return fmt.Errorf("%w: unknown environment %q", ErrInvalidConfig, env)
+	}
+
+	prefix := "environments." + key + "."
+	for _, k := range v.AllKeys() {
+		if strings.HasPrefix(k, prefix) {
+			v.Set("migrator."+strings.TrimPrefix(k, prefix), v.Get(k))
+		}
 	}
 
-	return &config
+	return nil
 }
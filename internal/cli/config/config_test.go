@@ -0,0 +1,238 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `
+migrator:
+  dsn: $BASE_DSN
+  dir: ./file-migrations
+  table_name: file_migrations
+
+environments:
+  prod:
+    dsn: postgres://prod
+    schema: service
+    safety:
+      disable_down: true
+
+logger:
+  level: WARNING
+`
+
+func writeConfig(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(testConfig), 0o600))
+
+	return path
+}
+
+func lookupEnv(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func TestLoad(t *testing.T) {
+	configFile := writeConfig(t)
+
+	tests := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		expected MigratorConf
+		logLevel string
+		rest     []string
+	}{
+		{
+			name: "defaults and flags",
+			args: []string{"-dsn=postgres://flag", "up"},
+			expected: MigratorConf{
+				DSN:            "postgres://flag",
+				Dir:            DefaultDir,
+				TableName:      DefaultTableName,
+				Numbering:      NumberingTimestamp,
+				SeedsDir:       DefaultSeedsDir,
+				SeedsTableName: DefaultSeedsTableName,
+			},
+			logLevel: DefaultLogLevel,
+			rest:     []string{"up"},
+		},
+		{
+			name: "file with expanded env",
+			args: []string{"-config=" + configFile, "create", "name"},
+			env:  map[string]string{"BASE_DSN": "postgres://base"},
+			expected: MigratorConf{
+				DSN:            "postgres://base",
+				Dir:            "./file-migrations",
+				TableName:      "file_migrations",
+				Numbering:      NumberingTimestamp,
+				SeedsDir:       DefaultSeedsDir,
+				SeedsTableName: DefaultSeedsTableName,
+			},
+			logLevel: "WARNING",
+			rest:     []string{"create", "name"},
+		},
+		{
+			name: "env variables override file",
+			args: []string{"-config=" + configFile, "up"},
+			env: map[string]string{
+				"BASE_DSN":             "postgres://base",
+				"GOMIGRATOR_DSN":       "postgres://env",
+				"GOMIGRATOR_LOG_LEVEL": "DEBUG",
+			},
+			expected: MigratorConf{
+				DSN:            "postgres://env",
+				Dir:            "./file-migrations",
+				TableName:      "file_migrations",
+				Numbering:      NumberingTimestamp,
+				SeedsDir:       DefaultSeedsDir,
+				SeedsTableName: DefaultSeedsTableName,
+			},
+			logLevel: "DEBUG",
+			rest:     []string{"up"},
+		},
+		{
+			name: "flags override env variables and file",
+			args: []string{"-config=" + configFile, "-dsn=postgres://flag", "-tableName=flag_migrations", "up"},
+			env:  map[string]string{"GOMIGRATOR_DSN": "postgres://env"},
+			expected: MigratorConf{
+				DSN:            "postgres://flag",
+				Dir:            "./file-migrations",
+				TableName:      "flag_migrations",
+				Numbering:      NumberingTimestamp,
+				SeedsDir:       DefaultSeedsDir,
+				SeedsTableName: DefaultSeedsTableName,
+			},
+			logLevel: "WARNING",
+			rest:     []string{"up"},
+		},
+		{
+			name: "environment from env variable",
+			args: []string{"-config=" + configFile, "status"},
+			env:  map[string]string{EnvVariable: "prod"},
+			expected: MigratorConf{
+				DSN:            "postgres://prod",
+				Dir:            "./file-migrations",
+				TableName:      "file_migrations",
+				Numbering:      NumberingTimestamp,
+				Schema:         "service",
+				SeedsDir:       DefaultSeedsDir,
+				SeedsTableName: DefaultSeedsTableName,
+				Env:            "prod",
+				Safety:         SafetyConf{DisableDown: true},
+			},
+			logLevel: "WARNING",
+			rest:     []string{"status"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader := &Loader{Args: tt.args, LookupEnv: lookupEnv(tt.env)}
+
+			cfg, rest, err := loader.Load()
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, cfg.Migrator)
+			assert.Equal(t, tt.logLevel, cfg.Logger.Level)
+			assert.Equal(t, tt.rest, rest)
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	configFile := writeConfig(t)
+
+	noEnvironmentsFile := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(noEnvironmentsFile, []byte("migrator:\n  dir: ./migrations\n"), 0o600))
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"empty dir", []string{"-dsn=postgres://flag", "-dir=", "up"}},
+		{"unknown environment", []string{"-config=" + configFile, "-env=stage", "up"}},
+		{"no environments in config file", []string{"-config=" + noEnvironmentsFile, "-env=dev", "up"}},
+		{"missing config file", []string{"-config=" + configFile + ".missing", "up"}},
+		{"unknown flag", []string{"-unknown", "up"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader := &Loader{Args: tt.args, LookupEnv: lookupEnv(nil)}
+
+			_, _, err := loader.Load()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := &Config{}
+
+	err := cfg.Validate()
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.ErrorContains(t, err, "dir is not set")
+	assert.ErrorContains(t, err, "table_name is not set")
+
+	err = cfg.ValidateConnection()
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.ErrorContains(t, err, "neither dsn nor host is set")
+}
+
+func TestLoadVariables(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+migrator:
+  dsn: postgres://base
+  variables:
+    app_user: app
+    readonly_user: reader
+environments:
+  prod:
+    schema: billing
+    strict_variables: true
+    variables:
+      app_user: billing_app
+`), 0o600))
+
+	env := map[string]string{"GOMIGRATOR_VAR_READONLY_USER": "billing_reader", "GOMIGRATOR_VAR_": "ignored"}
+	loader := &Loader{
+		Args:      []string{"-config=" + path, "-env=prod", "up"},
+		LookupEnv: lookupEnv(env),
+		Environ: func() []string {
+			environ := make([]string, 0, len(env))
+			for key, value := range env {
+				environ = append(environ, key+"="+value)
+			}
+
+			return environ
+		},
+	}
+
+	cfg, _, err := loader.Load()
+	require.NoError(t, err)
+
+	assert.True(t, cfg.Migrator.StrictVariables)
+	assert.Equal(t, map[string]string{
+		"app_user":      "billing_app",
+		"readonly_user": "billing_reader",
+		"schema":        "billing",
+		"env":           "prod",
+	}, cfg.Migrator.PlaceholderValues())
+
+	// Mistyped flag doesn't turn strict mode off silently.
+	env["GOMIGRATOR_STRICT_VARIABLES"] = "yes"
+	_, _, err = loader.Load()
+	require.ErrorIs(t, err, ErrInvalidConfig)
+}
@@ -0,0 +1,239 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	DefaultDriver = "postgresql"
+	DefaultPort   = "5432"
+)
+
+// ConnectionConf parts of DSN, which are used if DSN is not set.
+type ConnectionConf struct {
+	Driver   string            `mapstructure:"driver"`
+	Host     string            `mapstructure:"host"`
+	Port     string            `mapstructure:"port"`
+	User     string            `mapstructure:"user"`
+	Password string            `mapstructure:"password"`
+	Database string            `mapstructure:"database"`
+	SSLMode  string            `mapstructure:"sslmode"`
+	Options  map[string]string `mapstructure:"options"`
+
+	// Settings of session, which are sent to Postgres on connect.
+	StatementTimeout string `mapstructure:"statement_timeout"`
+	LockTimeout      string `mapstructure:"lock_timeout"`
+	ApplicationName  string `mapstructure:"application_name"`
+
+	// Files of TLS certificates and key (for sslmode verify-ca and verify-full).
+	SSLRootCert string `mapstructure:"sslrootcert"`
+	SSLCert     string `mapstructure:"sslcert"`
+	SSLKey      string `mapstructure:"sslkey"`
+
+	// File which contains just password (e.g. mounted Kubernetes secret).
+	PasswordFile string `mapstructure:"password_file"`
+
+	// File in PGPASSFILE format (hostname:port:database:username:password).
+	PassFile string `mapstructure:"passfile"`
+}
+
+// BuildDSN assembles DSN from parts, reading password from files if needed.
+func (c *ConnectionConf) BuildDSN() (string, error) {
+	if err := c.resolvePassword(); err != nil {
+		return "", err
+	}
+
+	driver := c.Driver
+	if driver == "" {
+		driver = DefaultDriver
+	}
+
+	// Other drivers use their own default ports.
+	port := c.Port
+	if port == "" && (driver == "postgres" || driver == "postgresql" || driver == "pgx") {
+		port = DefaultPort
+	}
+
+	host := c.Host
+	if port != "" {
+		host = net.JoinHostPort(c.Host, port)
+	}
+
+	u := &url.URL{
+		Scheme: driver,
+		Host:   host,
+		Path:   "/" + c.Database,
+	}
+
+	if c.User != "" {
+		if c.Password != "" {
+			u.User = url.UserPassword(c.User, c.Password)
+		} else {
+			u.User = url.User(c.User)
+		}
+	}
+
+	u.RawQuery = c.params().Encode()
+
+	return u.String(), nil
+}
+
+// ApplyParams adds connection parameters (sslmode, timeouts, options, etc.) to DSN,
+// parameters set in DSN explicitly are kept.
+func (c *ConnectionConf) ApplyParams(dsn string) (string, error) {
+	params := c.params()
+	if len(params) == 0 {
+		return dsn, nil
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme == "" {
+		return "", fmt.Errorf("%w: connection parameters can be added only to dsn in URL format", ErrInvalidConfig)
+	}
+
+	query := u.Query()
+	for key := range params {
+		if !query.Has(key) {
+			query.Set(key, params.Get(key))
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// Parameters of connection, which are passed in query of DSN.
+func (c *ConnectionConf) params() url.Values {
+	query := url.Values{}
+
+	settings := map[string]string{
+		"sslmode":           c.SSLMode,
+		"sslrootcert":       c.SSLRootCert,
+		"sslcert":           c.SSLCert,
+		"sslkey":            c.SSLKey,
+		"statement_timeout": c.StatementTimeout,
+		"lock_timeout":      c.LockTimeout,
+		"application_name":  c.ApplicationName,
+	}
+	for key, value := range settings {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	for key, value := range c.Options {
+		query.Set(key, value)
+	}
+
+	return query
+}
+
+// Secrets returns password in raw and URL-encoded forms to hide them in logs.
+func (c *ConnectionConf) Secrets() []string {
+	if c.Password == "" {
+		return nil
+	}
+
+	return []string{c.Password, url.PathEscape(c.Password), url.QueryEscape(c.Password)}
+}
+
+// Read password from password file or from passfile, if it is not set.
+func (c *ConnectionConf) resolvePassword() error {
+	if c.Password != "" {
+		return nil
+	}
+
+	if c.PasswordFile != "" {
+		data, err := os.ReadFile(c.PasswordFile)
+		if err != nil {
+			return fmt.Errorf("couldn't read password file: %w", err)
+		}
+
+		c.Password = strings.TrimSpace(string(data))
+
+		return nil
+	}
+
+	if c.PassFile != "" {
+		password, err := c.passwordFromPassFile()
+		if err != nil {
+			return err
+		}
+
+		c.Password = password
+	}
+
+	return nil
+}
+
+// Find password for connection in PGPASSFILE format, "*" matches any value.
+func (c *ConnectionConf) passwordFromPassFile() (string, error) {
+	file, err := os.Open(c.PassFile)
+	if err != nil {
+		return "", fmt.Errorf("couldn't read passfile: %w", err)
+	}
+	defer file.Close()
+
+	port := c.Port
+	if port == "" {
+		port = DefaultPort
+	}
+	expected := []string{c.Host, port, c.Database, c.User}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := splitPassLine(line)
+		if len(fields) != 5 {
+			continue
+		}
+
+		matched := true
+		for i, value := range expected {
+			if fields[i] != "*" && fields[i] != value {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return fields[4], nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("couldn't read passfile: %w", err)
+	}
+
+	return "", nil
+}
+
+// Split line of passfile by ":", supporting "\:" and "\\" escapes.
+func splitPassLine(line string) []string {
+	fields := make([]string, 0, 5)
+
+	var field strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line):
+			i++
+			field.WriteByte(line[i])
+		case line[i] == ':':
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteByte(line[i])
+		}
+	}
+
+	return append(fields, field.String())
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildDSN(t *testing.T) {
+	dir := t.TempDir()
+
+	passwordFile := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("from:file\n"), 0o600))
+
+	passFile := filepath.Join(dir, "pgpass")
+	require.NoError(t, os.WriteFile(passFile, []byte(
+		"# hostname:port:database:username:password\n"+
+			"other:5432:app:app:wrong\n"+
+			"db:*:app:app:pass\\:word\n",
+	), 0o600))
+
+	tests := []struct {
+		name     string
+		conn     ConnectionConf
+		expected string
+	}{
+		{
+			name: "all parts",
+			conn: ConnectionConf{
+				Host:     "db",
+				Port:     "6432",
+				User:     "app",
+				Password: "p@ss",
+				Database: "app",
+				SSLMode:  "disable",
+				Options:  map[string]string{"application_name": "gomigrator"},
+			},
+			expected: "postgresql://app:p%40ss@db:6432/app?application_name=gomigrator&sslmode=disable",
+		},
+		{
+			name:     "password file",
+			conn:     ConnectionConf{Host: "db", User: "app", Database: "app", PasswordFile: passwordFile},
+			expected: "postgresql://app:from%3Afile@db:5432/app",
+		},
+		{
+			name:     "passfile",
+			conn:     ConnectionConf{Host: "db", User: "app", Database: "app", PassFile: passFile},
+			expected: "postgresql://app:pass%3Aword@db:5432/app",
+		},
+		{
+			name:     "no password",
+			conn:     ConnectionConf{Driver: "postgres", Host: "db", User: "app", Database: "app"},
+			expected: "postgres://app@db:5432/app",
+		},
+		{
+			name: "session and tls settings",
+			conn: ConnectionConf{
+				Driver:           "pgx",
+				Host:             "db",
+				User:             "app",
+				Database:         "app",
+				SSLMode:          "verify-full",
+				SSLRootCert:      "/certs/ca.pem",
+				StatementTimeout: "30s",
+				LockTimeout:      "5s",
+				ApplicationName:  "gomigrator",
+			},
+			expected: "pgx://app@db:5432/app?application_name=gomigrator&lock_timeout=5s&" +
+				"sslmode=verify-full&sslrootcert=%2Fcerts%2Fca.pem&statement_timeout=30s",
+		},
+		{
+			name:     "mysql default port",
+			conn:     ConnectionConf{Driver: "mysql", Host: "db", User: "app", Password: "secret", Database: "shop"},
+			expected: "mysql://app:secret@db/shop",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dsn, err := tt.conn.BuildDSN()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, dsn)
+		})
+	}
+}
+
+func TestLoadDSNFromParts(t *testing.T) {
+	loader := &Loader{
+		Args: []string{"up"},
+		LookupEnv: lookupEnv(map[string]string{
+			"GOMIGRATOR_HOST":     "db",
+			"GOMIGRATOR_USER":     "app",
+			"GOMIGRATOR_PASSWORD": "secret",
+			"GOMIGRATOR_DATABASE": "app",
+		}),
+	}
+
+	cfg, _, err := loader.Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "postgresql://app:secret@db:5432/app", cfg.Migrator.DSN)
+	assert.Contains(t, cfg.Migrator.Secrets(), "secret")
+}
+
+func TestApplyParams(t *testing.T) {
+	conn := ConnectionConf{LockTimeout: "5s", ApplicationName: "gomigrator"}
+
+	dsn, err := conn.ApplyParams("pgx://app@db:5432/app?application_name=billing")
+	require.NoError(t, err)
+	assert.Equal(t, "pgx://app@db:5432/app?application_name=billing&lock_timeout=5s", dsn)
+
+	_, err = conn.ApplyParams("host=db user=app")
+	require.ErrorIs(t, err, ErrInvalidConfig)
+
+	dsn, err = (&ConnectionConf{}).ApplyParams("host=db user=app")
+	require.NoError(t, err)
+	assert.Equal(t, "host=db user=app", dsn)
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/EvgenyRomanov/sql-migrator/internal/cli/config"
+)
+
+var (
+	ErrDownDisabled = errors.New("rollback of migrations is disabled for this environment")
+	ErrNotConfirmed = errors.New("command was not confirmed")
+)
+
+// Commands which change DB state.
+var changingCommands = map[string]bool{
+	"up":     true,
+	"down":   true,
+	"redo":   true,
+	"repair": true,
+	"seed":   true,
+}
+
+// Commands which change DB state only with flag.
+var changingFlags = map[string]string{
+	// Scratch schema is created in target database and migrations are applied to it.
+	"drift": "in-schema",
+}
+
+// Check safety settings of environment before running command with args.
+func checkSafety(cfg *config.MigratorConf, args []string, in io.Reader, out io.Writer) error {
+	name := args[0]
+
+	if cfg.Safety.DisableDown && (name == "down" || name == "redo") {
+		return ErrDownDisabled
+	}
+
+	if !cfg.Safety.Confirm || !(changingCommands[name] || hasFlag(args[1:], changingFlags[name])) {
+		return nil
+	}
+
+	// Confirm by typing name of environment (or command if there is no environment).
+	expected := cfg.Env
+	if expected == "" {
+		expected = name
+	}
+
+	fmt.Fprintf(out, "You are going to run '%s' for environment %q. Type %q to continue: ", name, cfg.Env, expected)
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("can't read confirmation: %w", err)
+	}
+
+	if strings.TrimSpace(answer) != expected {
+		return ErrNotConfirmed
+	}
+
+	return nil
+}
+
+// Check if boolean flag is set in args of command.
+func hasFlag(args []string, flag string) bool {
+	if flag == "" {
+		return false
+	}
+
+	for _, arg := range args {
+		name, value, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if strings.HasPrefix(arg, "-") && name == flag && value != "false" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/EvgenyRomanov/sql-migrator/internal/cli/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSafety(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		safety   config.SafetyConf
+		command  string
+		input    string
+		err      error
+		prompted bool
+	}{
+		{"no safety settings", "prod", config.SafetyConf{}, "down", "", nil, false},
+		{"down disabled", "prod", config.SafetyConf{DisableDown: true}, "down", "", ErrDownDisabled, false},
+		{"redo disabled", "prod", config.SafetyConf{DisableDown: true}, "redo", "", ErrDownDisabled, false},
+		{"up with disabled down", "prod", config.SafetyConf{DisableDown: true}, "up", "", nil, false},
+		{"confirmed", "prod", config.SafetyConf{Confirm: true}, "up", "prod\n", nil, true},
+		{"confirmed without newline", "prod", config.SafetyConf{Confirm: true}, "seed", "prod", nil, true},
+		{"wrong confirmation", "prod", config.SafetyConf{Confirm: true}, "up", "dev\n", ErrNotConfirmed, true},
+		{"no confirmation", "prod", config.SafetyConf{Confirm: true}, "repair", "", ErrNotConfirmed, true},
+		{"confirmed by command name", "", config.SafetyConf{Confirm: true}, "down", "down\n", nil, true},
+		{"read-only command", "prod", config.SafetyConf{Confirm: true}, "status", "", nil, false},
+		{"drift", "prod", config.SafetyConf{Confirm: true}, "drift -scratch=postgres://scratch", "", nil, false},
+		{"drift in schema", "prod", config.SafetyConf{Confirm: true}, "drift -in-schema", "", ErrNotConfirmed, true},
+		{"drift in schema confirmed", "prod", config.SafetyConf{Confirm: true}, "drift --in-schema=1", "prod\n", nil, true},
+		{"drift not in schema", "prod", config.SafetyConf{Confirm: true}, "drift -in-schema=false", "", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.MigratorConf{Env: tt.env, Safety: tt.safety}
+			out := &bytes.Buffer{}
+
+			err := checkSafety(cfg, strings.Fields(tt.command), strings.NewReader(tt.input), out)
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if tt.prompted {
+				assert.Contains(t, out.String(), "You are going to run '"+strings.Fields(tt.command)[0]+"'")
+			} else {
+				assert.Empty(t, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package pgx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDetails(t *testing.T) {
+	query := "CREATE TABLE users (id int);\nCREATE TABLE orders (id int REFERENCES user(id));\n"
+	pgErr := &pgconn.PgError{
+		Severity: "ERROR",
+		Code:     "42P01",
+		Message:  `relation "user" does not exist`,
+		Position: 69,
+		Hint:     "Perhaps you meant to reference the table \"users\".",
+	}
+
+	err := withDetails(pgErr, query)
+	require.ErrorIs(t, err, pgErr)
+	assert.Equal(
+		t,
+		`ERROR: relation "user" does not exist (SQLSTATE 42P01): `+
+			`position 69 (line 2, column 40), hint: Perhaps you meant to reference the table "users".`,
+		err.Error(),
+	)
+
+	other := errors.New("connection reset")
+	assert.Equal(t, other, withDetails(other, query))
+}
+
+func TestConnString(t *testing.T) {
+	assert.Equal(
+		t,
+		"postgres://app@localhost:5432/app?statement_timeout=5s",
+		connString("pgx://app@localhost:5432/app?statement_timeout=5s"),
+	)
+}
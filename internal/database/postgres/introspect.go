@@ -0,0 +1,247 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/EvgenyRomanov/sql-migrator/pkg/schema"
+)
+
+// Objects of system schemas and extensions are not a part of application schema.
+const userObjects = `
+	n.nspname NOT IN ('pg_catalog', 'information_schema')
+	AND n.nspname NOT LIKE 'pg_toast%%'
+	AND n.nspname NOT LIKE 'pg_temp%%'
+	AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.objid = %s AND d.deptype = 'e')
+`
+
+// Types of columns, which are created as serial ones.
+var serialTypes = map[string]string{
+	"smallint": "smallserial",
+	"integer":  "serial",
+	"bigint":   "bigserial",
+}
+
+// Introspect reads schema from Postgres catalog.
+func (p Postgres) Introspect(ignoredTables ...string) (*schema.Schema, error) {
+	s := &schema.Schema{}
+
+	// Constraints and indexes are read only for tables with columns, so ignored tables are skipped by columns.
+	ignored := append([]string{p.tableName}, ignoredTables...)
+
+	if err := p.introspectSequences(s); err != nil {
+		return nil, fmt.Errorf("can't read sequences: %w", err)
+	}
+
+	if err := p.introspectColumns(s, ignored); err != nil {
+		return nil, fmt.Errorf("can't read columns: %w", err)
+	}
+
+	if err := p.introspectConstraints(s); err != nil {
+		return nil, fmt.Errorf("can't read constraints: %w", err)
+	}
+
+	if err := p.introspectIndexes(s); err != nil {
+		return nil, fmt.Errorf("can't read indexes: %w", err)
+	}
+
+	if err := p.introspectViews(s); err != nil {
+		return nil, fmt.Errorf("can't read views: %w", err)
+	}
+
+	if err := p.introspectFunctions(s); err != nil {
+		return nil, fmt.Errorf("can't read functions: %w", err)
+	}
+
+	s.Sort()
+
+	return s, nil
+}
+
+func (p Postgres) introspectSequences(s *schema.Schema) error {
+	// Sequences owned by serial and identity columns are created with columns.
+	query := `
+		SELECT n.nspname || '.' || c.relname,
+			format('AS %s INCREMENT BY %s MINVALUE %s MAXVALUE %s START WITH %s CACHE %s%s',
+				format_type(seq.seqtypid, NULL), seq.seqincrement, seq.seqmin, seq.seqmax, seq.seqstart, seq.seqcache,
+				CASE WHEN seq.seqcycle THEN ' CYCLE' ELSE '' END)
+		FROM pg_sequence seq
+		JOIN pg_class c ON c.oid = seq.seqrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE NOT EXISTS (
+				SELECT 1 FROM pg_depend d
+				WHERE d.objid = c.oid AND d.classid = 'pg_class'::regclass AND d.deptype IN ('a', 'i')
+			)
+			AND ` + fmt.Sprintf(userObjects, "c.oid") + `
+		ORDER BY 1;
+	`
+
+	return p.query(query, func(rows *sql.Rows) error {
+		sequence := &schema.Sequence{}
+		if err := rows.Scan(&sequence.Name, &sequence.Definition); err != nil {
+			return err
+		}
+		s.Sequences = append(s.Sequences, sequence)
+
+		return nil
+	})
+}
+
+func (p Postgres) introspectColumns(s *schema.Schema, ignored []string) error {
+	// Column owning sequence of its default is serial.
+	query := `
+		SELECT n.nspname || '.' || c.relname, a.attname, format_type(a.atttypid, a.atttypmod),
+			NOT a.attnotnull, COALESCE(pg_get_expr(ad.adbin, ad.adrelid), ''),
+			CASE a.attidentity WHEN 'a' THEN 'ALWAYS' WHEN 'd' THEN 'BY DEFAULT' ELSE '' END,
+			EXISTS (
+				SELECT 1 FROM pg_depend d
+				JOIN pg_class sc ON sc.oid = d.objid AND sc.relkind = 'S'
+				WHERE d.refobjid = c.oid AND d.refobjsubid = a.attnum AND d.deptype = 'a'
+			)
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+		LEFT JOIN pg_attrdef ad ON ad.adrelid = c.oid AND ad.adnum = a.attnum
+		WHERE c.relkind IN ('r', 'p')
+			AND c.oid NOT IN (
+				SELECT to_regclass(t) FROM unnest(string_to_array($1, ',')) t WHERE to_regclass(t) IS NOT NULL
+			)
+			AND ` + fmt.Sprintf(userObjects, "c.oid") + `
+		ORDER BY 1, a.attnum;
+	`
+
+	return p.query(query, func(rows *sql.Rows) error {
+		var tableName string
+		var owns bool
+		column := &schema.Column{}
+		err := rows.Scan(&tableName, &column.Name, &column.Type, &column.Nullable, &column.Default, &column.Identity, &owns)
+		if err != nil {
+			return err
+		}
+
+		if serial, ok := serialTypes[column.Type]; ok && owns && strings.HasPrefix(column.Default, "nextval(") {
+			column.Type, column.Default = serial, ""
+		}
+
+		table := s.Table(tableName)
+		if table == nil {
+			table = &schema.Table{Name: tableName}
+			s.Tables = append(s.Tables, table)
+		}
+		table.Columns = append(table.Columns, column)
+
+		return nil
+	}, strings.Join(ignored, ","))
+}
+
+func (p Postgres) introspectConstraints(s *schema.Schema) error {
+	query := `
+		SELECT n.nspname || '.' || c.relname, con.conname, pg_get_constraintdef(con.oid)
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE ` + fmt.Sprintf(userObjects, "c.oid") + `
+		ORDER BY 1, 2;
+	`
+
+	return p.query(query, func(rows *sql.Rows) error {
+		var tableName string
+		constraint := &schema.Constraint{}
+		if err := rows.Scan(&tableName, &constraint.Name, &constraint.Definition); err != nil {
+			return err
+		}
+
+		if table := s.Table(tableName); table != nil {
+			table.Constraints = append(table.Constraints, constraint)
+		}
+
+		return nil
+	})
+}
+
+func (p Postgres) introspectIndexes(s *schema.Schema) error {
+	// Indexes of primary keys and unique constraints are created by constraints.
+	query := `
+		SELECT n.nspname || '.' || c.relname, i.relname, pg_get_indexdef(i.oid)
+		FROM pg_index x
+		JOIN pg_class i ON i.oid = x.indexrelid
+		JOIN pg_class c ON c.oid = x.indrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE NOT EXISTS (SELECT 1 FROM pg_constraint con WHERE con.conindid = x.indexrelid)
+			AND ` + fmt.Sprintf(userObjects, "c.oid") + `
+		ORDER BY 1, 2;
+	`
+
+	return p.query(query, func(rows *sql.Rows) error {
+		var tableName string
+		index := &schema.Index{}
+		if err := rows.Scan(&tableName, &index.Name, &index.Definition); err != nil {
+			return err
+		}
+
+		if table := s.Table(tableName); table != nil {
+			table.Indexes = append(table.Indexes, index)
+		}
+
+		return nil
+	})
+}
+
+func (p Postgres) introspectViews(s *schema.Schema) error {
+	query := `
+		SELECT n.nspname || '.' || c.relname, pg_get_viewdef(c.oid)
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind = 'v' AND ` + fmt.Sprintf(userObjects, "c.oid") + `
+		ORDER BY 1;
+	`
+
+	return p.query(query, func(rows *sql.Rows) error {
+		view := &schema.View{}
+		if err := rows.Scan(&view.Name, &view.Definition); err != nil {
+			return err
+		}
+		s.Views = append(s.Views, view)
+
+		return nil
+	})
+}
+
+func (p Postgres) introspectFunctions(s *schema.Schema) error {
+	query := `
+		SELECT n.nspname || '.' || f.proname || '(' || pg_get_function_identity_arguments(f.oid) || ')',
+			pg_get_functiondef(f.oid)
+		FROM pg_proc f
+		JOIN pg_namespace n ON n.oid = f.pronamespace
+		WHERE f.prokind IN ('f', 'p') AND ` + fmt.Sprintf(userObjects, "f.oid") + `
+		ORDER BY 1;
+	`
+
+	return p.query(query, func(rows *sql.Rows) error {
+		function := &schema.Function{}
+		if err := rows.Scan(&function.Name, &function.Definition); err != nil {
+			return err
+		}
+		s.Functions = append(s.Functions, function)
+
+		return nil
+	})
+}
+
+// Run query and call scan for every row.
+func (p Postgres) query(query string, scan func(rows *sql.Rows) error, args ...any) error {
+	rows, err := p.db.QueryContext(p.ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
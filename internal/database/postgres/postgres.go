@@ -9,7 +9,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/EvgenyRomanov/sql-migrator/internal/database"
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database"
 	// Dynamic build.
 	_ "github.com/lib/pq"
 )
@@ -21,6 +21,12 @@ type Postgres struct {
 	db        *sql.DB
 	tableName string
 	ctx       context.Context
+
+	// Session which holds advisory lock.
+	lockConn *sql.Conn
+
+	// Database is opened by application and isn't closed by Close.
+	shared bool
 }
 
 // Init itself.
@@ -42,42 +48,85 @@ func (p Postgres) Open(url string, tableName string) (database.Driver, error) {
 		return nil, err
 	}
 
-	// create new DB instance
-	instance := &Postgres{
+	return New(db, tableName), nil
+}
+
+// New returns driver on top of opened database, it is used by drivers with other client libraries.
+func New(db *sql.DB, tableName string) *Postgres {
+	return &Postgres{
 		db:        db,
 		tableName: tableName,
-		ctx:       ctx,
+		ctx:       context.Background(),
 	}
+}
+
+// OpenDB returns driver on top of database opened by application.
+func (p Postgres) OpenDB(db *sql.DB, tableName string) (database.Driver, error) {
+	instance := New(db, tableName)
+	instance.shared = true
 
 	return instance, nil
 }
 
-func (p Postgres) Close() error {
+func (p *Postgres) Close() error {
+	// Connection returns to pool, so lock shouldn't stay on it.
+	if p.lockConn != nil {
+		p.Unlock()
+	}
+
+	if p.shared {
+		return nil
+	}
+
 	if err := p.db.Close(); err != nil {
 		return fmt.Errorf("conn close error: %w", err)
 	}
 	return nil
 }
 
-func (p Postgres) Lock() error {
-	row := p.db.QueryRowContext(p.ctx, "SELECT pg_try_advisory_lock($1)", DefaultLockID)
-	var locked string
+// Lock acquires session-level advisory lock, so dedicated connection is used for it
+// (connections of pool, e.g. shared with application, may be different for lock and unlock).
+func (p *Postgres) Lock() error {
+	if p.lockConn != nil {
+		return database.ErrLocked
+	}
+
+	conn, err := p.db.Conn(p.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection for lock: %w", err)
+	}
+
+	row := conn.QueryRowContext(p.ctx, "SELECT pg_try_advisory_lock($1)", DefaultLockID)
+	var locked bool
 
 	if err := row.Scan(&locked); err != nil {
+		conn.Close()
 		return fmt.Errorf("failed to execute pg_try_advisory_lock: %w", err)
 	}
 
-	if locked != "" {
+	if locked {
 		// A session-level advisory lock was acquired.
+		p.lockConn = conn
 		return nil
 	}
 
+	conn.Close()
+
 	return database.ErrLocked
 }
 
-func (p Postgres) Unlock() error {
+func (p *Postgres) Unlock() error {
+	if p.lockConn == nil {
+		return database.ErrUnlock
+	}
+
+	defer func() {
+		p.lockConn.Close()
+		p.lockConn = nil
+	}()
+
 	var unlocked bool
-	row := p.db.QueryRowContext(p.ctx, "SELECT pg_advisory_unlock($1)", DefaultLockID)
+	row := p.lockConn.QueryRowContext(p.ctx, "SELECT pg_advisory_unlock($1)", DefaultLockID)
 
 	if err := row.Scan(&unlocked); err != nil {
 		return fmt.Errorf("failed to execute pg_advisory_unlock: %w", err)
@@ -119,14 +168,22 @@ func (p Postgres) Run(migration io.Reader) error {
 }
 
 func (p Postgres) SetVersion(version int64) error {
+	return p.SetStatus(version, database.StatusApplied, "")
+}
+
+func (p Postgres) SetStatus(version int64, status string, message string) error {
 	const query = `
-		INSERT INTO %s (version, applied_at)
-		VALUES (%d, $1)
+		INSERT INTO %s (version, applied_at, status, error)
+		VALUES (%d, $1, $2, $3)
+		ON CONFLICT (version) DO UPDATE
+		SET applied_at = EXCLUDED.applied_at, status = EXCLUDED.status, error = EXCLUDED.error
 	`
 	_, err := p.db.ExecContext(
 		p.ctx,
 		fmt.Sprintf(query, p.tableName, version),
 		time.Now(),
+		status,
+		message,
 	)
 
 	return err
@@ -146,11 +203,16 @@ func (p Postgres) DeleteVersion(version int64) error {
 // Version returns the currently active version.
 // When no migration has been applied, it must return version -1.
 func (p Postgres) Version() (version int64, err error) {
-	const query = `SELECT version FROM %s ORDER BY version DESC LIMIT 1;`
+	const query = `
+		SELECT version FROM %s
+		WHERE version IS NOT NULL AND status = $1
+		ORDER BY version DESC LIMIT 1;
+	`
 
 	row := p.db.QueryRowContext(
 		p.ctx,
 		fmt.Sprintf(query, p.tableName),
+		database.StatusApplied,
 	)
 
 	err = row.Scan(
@@ -171,7 +233,11 @@ func (p Postgres) Version() (version int64, err error) {
 }
 
 func (p Postgres) List() (versions []*database.ListInfo, err error) {
-	const query = `SELECT version, applied_at FROM %s ORDER BY version;`
+	const query = `
+		SELECT version, applied_at, status, error FROM %s
+		WHERE version IS NOT NULL
+		ORDER BY version;
+	`
 
 	rows, err := p.db.QueryContext(p.ctx, fmt.Sprintf(query, p.tableName))
 	if err != nil {
@@ -184,6 +250,8 @@ func (p Postgres) List() (versions []*database.ListInfo, err error) {
 		err = rows.Scan(
 			&v.Version,
 			&v.AppliedAt,
+			&v.Status,
+			&v.Error,
 		)
 		if err != nil {
 			return nil, err
@@ -199,14 +267,83 @@ func (p Postgres) List() (versions []*database.ListInfo, err error) {
 	return versions, nil
 }
 
+func (p Postgres) SetRepeatable(name string, checksum string) error {
+	const query = `
+		INSERT INTO %s (name, checksum, applied_at, status)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (name) DO UPDATE
+		SET checksum = EXCLUDED.checksum, applied_at = EXCLUDED.applied_at
+	`
+	_, err := p.db.ExecContext(
+		p.ctx,
+		fmt.Sprintf(query, p.tableName),
+		name,
+		checksum,
+		time.Now(),
+		database.StatusApplied,
+	)
+
+	return err
+}
+
+func (p Postgres) ListRepeatable() (migrations []*database.RepeatableInfo, err error) {
+	const query = `
+		SELECT name, checksum, applied_at FROM %s
+		WHERE name IS NOT NULL
+		ORDER BY name;
+	`
+
+	rows, err := p.db.QueryContext(p.ctx, fmt.Sprintf(query, p.tableName))
+	if err != nil {
+		return []*database.RepeatableInfo{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		r := &database.RepeatableInfo{}
+		err = rows.Scan(
+			&r.Name,
+			&r.Checksum,
+			&r.AppliedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return migrations, nil
+}
+
 func (p Postgres) PrepareTable() error {
+	// Create schema for table with qualified name.
+	if i := strings.Index(p.tableName, "."); i > 0 {
+		_, err := p.db.ExecContext(
+			p.ctx,
+			fmt.Sprintf(`CREATE SCHEMA IF NOT EXISTS %s;`, p.tableName[:i]),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
 	const query = `
 		CREATE TABLE IF NOT EXISTS %s (
 			id serial NOT NULL,
-			version bigint NOT NULL,
+			version bigint,
+			name text,
+			checksum varchar(64) NOT NULL DEFAULT '',
 			applied_at timestamp NOT NULL,
+			status varchar(16) NOT NULL DEFAULT 'applied',
+			error text NOT NULL DEFAULT '',
 			PRIMARY KEY(id),
-			UNIQUE(version)
+			UNIQUE(version),
+			UNIQUE(name)
 		);
 	`
 	_, err := p.db.ExecContext(
@@ -217,5 +354,68 @@ func (p Postgres) PrepareTable() error {
 		return err
 	}
 
+	return p.upgradeTable()
+}
+
+// Upgrade table created by previous versions: add missing columns one by one.
+// Repeatable migrations are stored by name without version.
+func (p Postgres) upgradeTable() error {
+	schemaName, tableName := "", p.tableName
+	if i := strings.Index(p.tableName, "."); i > 0 {
+		schemaName, tableName = p.tableName[:i], p.tableName[i+1:]
+	}
+
+	const columnsQuery = `
+		SELECT column_name, is_nullable = 'YES'
+		FROM information_schema.columns
+		WHERE table_schema = COALESCE(NULLIF($1, ''), current_schema()) AND table_name = $2;
+	`
+
+	columns := make(map[string]bool)
+	err := p.query(columnsQuery, func(rows *sql.Rows) error {
+		var name string
+		var nullable bool
+		if err := rows.Scan(&name, &nullable); err != nil {
+			return err
+		}
+		columns[name] = nullable
+
+		return nil
+	}, schemaName, tableName)
+	if err != nil {
+		return err
+	}
+
+	statements := make([]string, 0)
+	for _, column := range []struct{ name, definition string }{
+		{"status", "varchar(16) NOT NULL DEFAULT 'applied'"},
+		{"error", "text NOT NULL DEFAULT ''"},
+		{"name", "text"},
+		{"checksum", "varchar(64) NOT NULL DEFAULT ''"},
+	} {
+		if _, ok := columns[column.name]; !ok {
+			statements = append(statements, fmt.Sprintf(
+				"ALTER TABLE %s ADD COLUMN %s %s;", p.tableName, column.name, column.definition,
+			))
+		}
+	}
+
+	// Index has the same name as constraint of new table, so it isn't duplicated.
+	if _, ok := columns["name"]; !ok {
+		statements = append(statements, fmt.Sprintf(
+			"CREATE UNIQUE INDEX IF NOT EXISTS %s_name_key ON %s (name);", tableName, p.tableName,
+		))
+	}
+
+	if !columns["version"] {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN version DROP NOT NULL;", p.tableName))
+	}
+
+	for _, statement := range statements {
+		if _, err := p.db.ExecContext(p.ctx, statement); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
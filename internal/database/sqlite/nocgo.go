@@ -0,0 +1,34 @@
+//go:build !cgo
+
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database"
+)
+
+// ErrNoCGO is returned by SQLite driver of binary built without cgo, go-sqlite3 requires it.
+var ErrNoCGO = errors.New("SQLite driver is not available: gomigrator is built without cgo (CGO_ENABLED=0)")
+
+// SQLite is registered without cgo to report the reason instead of unknown driver.
+// Other methods are never called, because database can't be opened.
+type SQLite struct {
+	database.Driver
+}
+
+// Init itself.
+func init() {
+	driver := SQLite{}
+	database.Register("sqlite", &driver)
+	database.Register("sqlite3", &driver)
+}
+
+func (s SQLite) Open(_ string, _ string) (database.Driver, error) {
+	return nil, ErrNoCGO
+}
+
+func (s SQLite) OpenDB(_ *sql.DB, _ string) (database.Driver, error) {
+	return nil, ErrNoCGO
+}
@@ -0,0 +1,18 @@
+//go:build !cgo
+
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenWithoutCGO(t *testing.T) {
+	_, err := database.Open("sqlite://app.db", "migrations")
+	require.ErrorIs(t, err, ErrNoCGO)
+
+	_, err = database.OpenDB(nil, "sqlite", "migrations")
+	require.ErrorIs(t, err, ErrNoCGO)
+}
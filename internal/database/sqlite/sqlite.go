@@ -0,0 +1,320 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database"
+	"github.com/mattn/go-sqlite3"
+)
+
+// MemoryDSN is DSN of in-memory database, which lives until driver is closed.
+const MemoryDSN = "sqlite://:memory:"
+
+type SQLite struct {
+	db        *sql.DB
+	tableName string
+	ctx       context.Context
+
+	// Database is opened by application and isn't closed by Close.
+	shared bool
+}
+
+// Init itself.
+func init() {
+	driver := SQLite{}
+	database.Register("sqlite", &driver)
+	database.Register("sqlite3", &driver)
+}
+
+// Open database by DSN "sqlite://path/to/file.db?_busy_timeout=5000" or "sqlite://:memory:".
+// Query parameters are passed to go-sqlite3 as is.
+func (s SQLite) Open(url string, tableName string) (database.Driver, error) {
+	path := url[strings.Index(url, ":")+1:]
+	path = strings.TrimPrefix(path, "//")
+
+	if path == "" || strings.HasPrefix(path, "?") {
+		return nil, fmt.Errorf("%w: path to database file is not set", database.ErrParseDSN)
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite has single writer, and every connection to in-memory database opens a new database.
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+
+	ctx := context.Background()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	instance := &SQLite{
+		db:        db,
+		tableName: tableName,
+		ctx:       ctx,
+	}
+
+	// Lock table is required before migrations table is prepared.
+	if err := instance.prepareLockTable(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return instance, nil
+}
+
+// OpenDB returns driver on top of database opened by application.
+// In-memory database should be limited to one connection by application (db.SetMaxOpenConns(1)).
+func (s SQLite) OpenDB(db *sql.DB, tableName string) (database.Driver, error) {
+	instance := &SQLite{
+		db:        db,
+		tableName: tableName,
+		ctx:       context.Background(),
+		shared:    true,
+	}
+
+	if err := instance.prepareLockTable(); err != nil {
+		return nil, err
+	}
+
+	return instance, nil
+}
+
+func (s SQLite) Close() error {
+	if s.shared {
+		return nil
+	}
+
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("conn close error: %w", err)
+	}
+	return nil
+}
+
+// Lock inserts the only row into lock table, so another process fails to insert it.
+// Lock of crashed process should be removed manually (DELETE FROM <table>_lock).
+func (s SQLite) Lock() error {
+	_, err := s.db.ExecContext(
+		s.ctx,
+		fmt.Sprintf(`INSERT INTO %s (id, locked_at) VALUES (1, ?);`, s.lockTableName()),
+		time.Now(),
+	)
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrConstraint {
+		return database.ErrLocked
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to acquire lock: %w", err)
+	}
+
+	return nil
+}
+
+func (s SQLite) Unlock() error {
+	result, err := s.db.ExecContext(s.ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = 1;`, s.lockTableName()))
+	if err != nil {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return database.ErrUnlock
+	}
+
+	return nil
+}
+
+// Run migration statements in transaction (DDL is transactional in SQLite).
+func (s SQLite) Run(migration io.Reader) error {
+	readQuery, err := io.ReadAll(migration)
+	if err != nil {
+		return err
+	}
+
+	query := string(readQuery)
+	if strings.TrimSpace(query) == "" {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(s.ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(s.ctx, query); err != nil {
+		if errRollback := tx.Rollback(); errRollback != nil {
+			return fmt.Errorf("%w. Additional err: %w", err, errRollback)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s SQLite) SetVersion(version int64) error {
+	return s.SetStatus(version, database.StatusApplied, "")
+}
+
+func (s SQLite) SetStatus(version int64, status string, message string) error {
+	const query = `
+		INSERT INTO %s (version, applied_at, status, error)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (version) DO UPDATE
+		SET applied_at = excluded.applied_at, status = excluded.status, error = excluded.error;
+	`
+	_, err := s.db.ExecContext(s.ctx, fmt.Sprintf(query, s.tableName), version, time.Now(), status, message)
+
+	return err
+}
+
+func (s SQLite) DeleteVersion(version int64) error {
+	_, err := s.db.ExecContext(s.ctx, fmt.Sprintf(`DELETE FROM %s WHERE version = ?;`, s.tableName), version)
+
+	return err
+}
+
+// Version returns the currently active version.
+// When no migration has been applied, it must return version -1.
+func (s SQLite) Version() (version int64, err error) {
+	const query = `
+		SELECT version FROM %s
+		WHERE version IS NOT NULL AND status = ?
+		ORDER BY version DESC LIMIT 1;
+	`
+
+	err = s.db.QueryRowContext(s.ctx, fmt.Sprintf(query, s.tableName), database.StatusApplied).Scan(&version)
+
+	// If not migrations applied yet.
+	if errors.Is(err, sql.ErrNoRows) {
+		return -1, nil
+	}
+
+	if err != nil {
+		return -1, err
+	}
+
+	return version, nil
+}
+
+func (s SQLite) List() (versions []*database.ListInfo, err error) {
+	const query = `
+		SELECT version, applied_at, status, error FROM %s
+		WHERE version IS NOT NULL
+		ORDER BY version;
+	`
+
+	rows, err := s.db.QueryContext(s.ctx, fmt.Sprintf(query, s.tableName))
+	if err != nil {
+		return []*database.ListInfo{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		v := &database.ListInfo{}
+		if err := rows.Scan(&v.Version, &v.AppliedAt, &v.Status, &v.Error); err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, v)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+func (s SQLite) SetRepeatable(name string, checksum string) error {
+	const query = `
+		INSERT INTO %s (name, checksum, applied_at, status)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE
+		SET checksum = excluded.checksum, applied_at = excluded.applied_at;
+	`
+	_, err := s.db.ExecContext(
+		s.ctx,
+		fmt.Sprintf(query, s.tableName),
+		name,
+		checksum,
+		time.Now(),
+		database.StatusApplied,
+	)
+
+	return err
+}
+
+func (s SQLite) ListRepeatable() (migrations []*database.RepeatableInfo, err error) {
+	const query = `
+		SELECT name, checksum, applied_at FROM %s
+		WHERE name IS NOT NULL
+		ORDER BY name;
+	`
+
+	rows, err := s.db.QueryContext(s.ctx, fmt.Sprintf(query, s.tableName))
+	if err != nil {
+		return []*database.RepeatableInfo{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		r := &database.RepeatableInfo{}
+		if err := rows.Scan(&r.Name, &r.Checksum, &r.AppliedAt); err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return migrations, nil
+}
+
+func (s SQLite) PrepareTable() error {
+	const query = `
+		CREATE TABLE IF NOT EXISTS %s (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			version INTEGER UNIQUE,
+			name TEXT UNIQUE,
+			checksum TEXT NOT NULL DEFAULT '',
+			applied_at TIMESTAMP NOT NULL,
+			status TEXT NOT NULL DEFAULT 'applied',
+			error TEXT NOT NULL DEFAULT ''
+		);
+	`
+	_, err := s.db.ExecContext(s.ctx, fmt.Sprintf(query, s.tableName))
+
+	return err
+}
+
+func (s SQLite) prepareLockTable() error {
+	const query = `
+		CREATE TABLE IF NOT EXISTS %s (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			locked_at TIMESTAMP NOT NULL
+		);
+	`
+	_, err := s.db.ExecContext(s.ctx, fmt.Sprintf(query, s.lockTableName()))
+
+	return err
+}
+
+func (s SQLite) lockTableName() string {
+	return s.tableName + "_lock"
+}
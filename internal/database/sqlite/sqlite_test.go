@@ -0,0 +1,87 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriver(t *testing.T) {
+	driver, err := database.Open(MemoryDSN, "migrations")
+	require.NoError(t, err)
+	defer driver.Close()
+
+	require.NoError(t, driver.PrepareTable())
+
+	version, err := driver.Version()
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1), version)
+
+	require.NoError(t, driver.Run(strings.NewReader("CREATE TABLE users (id INTEGER); CREATE TABLE orders (id INTEGER);")))
+	require.NoError(t, driver.SetVersion(1))
+	require.NoError(t, driver.SetStatus(2, database.StatusFailed, "syntax error"))
+
+	version, err = driver.Version()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), version)
+
+	list, err := driver.List()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, database.StatusFailed, list[1].Status)
+	assert.Equal(t, "syntax error", list[1].Error)
+
+	require.NoError(t, driver.DeleteVersion(2))
+	list, err = driver.List()
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	require.NoError(t, driver.SetRepeatable("R_views.sql", "abc"))
+	require.NoError(t, driver.SetRepeatable("R_views.sql", "def"))
+	repeatable, err := driver.ListRepeatable()
+	require.NoError(t, err)
+	require.Len(t, repeatable, 1)
+	assert.Equal(t, "def", repeatable[0].Checksum)
+}
+
+func TestRunRollsBack(t *testing.T) {
+	driver, err := database.Open(MemoryDSN, "migrations")
+	require.NoError(t, err)
+	defer driver.Close()
+
+	err = driver.Run(strings.NewReader("CREATE TABLE users (id INTEGER); CREATE TABLE broken (;"))
+	require.Error(t, err)
+
+	// Statements before the failed one are rolled back.
+	require.NoError(t, driver.Run(strings.NewReader("CREATE TABLE users (id INTEGER);")))
+}
+
+func TestLock(t *testing.T) {
+	dsn := "sqlite://" + filepath.Join(t.TempDir(), "test.db")
+
+	first, err := database.Open(dsn, "migrations")
+	require.NoError(t, err)
+	defer first.Close()
+
+	second, err := database.Open(dsn, "migrations")
+	require.NoError(t, err)
+	defer second.Close()
+
+	require.NoError(t, first.Lock())
+	require.ErrorIs(t, second.Lock(), database.ErrLocked)
+	require.NoError(t, first.Unlock())
+	require.ErrorIs(t, first.Unlock(), database.ErrUnlock)
+	require.NoError(t, second.Lock())
+	require.NoError(t, second.Unlock())
+}
+
+func TestOpenWithoutPath(t *testing.T) {
+	_, err := database.Open("sqlite://", "migrations")
+	require.ErrorIs(t, err, database.ErrParseDSN)
+}
@@ -3,7 +3,8 @@ package stub
 import (
 	"io"
 
-	"github.com/EvgenyRomanov/sql-migrator/internal/database"
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database"
+	"github.com/EvgenyRomanov/sql-migrator/pkg/schema"
 )
 
 type Stub struct {
@@ -53,6 +54,10 @@ func (p *Stub) SetVersion(version int64) error {
 	return nil
 }
 
+func (p *Stub) SetStatus(_ int64, _ string, _ string) error {
+	return nil
+}
+
 func (p *Stub) DeleteVersion(_ int64) error {
 	return nil
 }
@@ -65,6 +70,18 @@ func (p *Stub) List() ([]*database.ListInfo, error) {
 	return p.list, nil
 }
 
+func (p *Stub) SetRepeatable(_ string, _ string) error {
+	return nil
+}
+
+func (p *Stub) ListRepeatable() ([]*database.RepeatableInfo, error) {
+	return make([]*database.RepeatableInfo, 0), nil
+}
+
 func (p *Stub) PrepareTable() error {
 	return nil
 }
+
+func (p *Stub) Introspect(_ ...string) (*schema.Schema, error) {
+	return &schema.Schema{}, nil
+}
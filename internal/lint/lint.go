@@ -0,0 +1,238 @@
+package lint
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EvgenyRomanov/sql-migrator/internal/parser"
+)
+
+const annotationPrefix = "-- +gomigrator"
+
+var (
+	// Naming convention of migration files: <version>_<name>.sql or R_<name>.sql for repeatable ones.
+	fileNameRe = regexp.MustCompile(`^(\d+|R)_[a-z0-9_]+\.sql$`)
+
+	// Statements, which should be commented.
+	dangerousStatements = []struct {
+		re   *regexp.Regexp
+		name string
+	}{
+		{regexp.MustCompile(`(?i)\bDROP\s+TABLE\b`), "DROP TABLE"},
+		{regexp.MustCompile(`(?i)\bALTER\s+COLUMN\s+\S+\s+(SET\s+DATA\s+)?TYPE\b`), "ALTER COLUMN TYPE"},
+	}
+)
+
+// Issue is a problem found in migration file.
+type Issue struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", i.File, i.Line, i.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", i.File, i.Message)
+}
+
+// Dir checks all migration files in dir. Names of files are checked by pattern
+// (file_pattern of migrator), by naming convention if pattern is empty.
+func Dir(dir string, pattern string) ([]Issue, error) {
+	nameRe, convention := fileNameRe, "<version>_<name>.sql convention"
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file pattern: %w", err)
+		}
+
+		nameRe, convention = re, "file pattern "+pattern
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]Issue, 0)
+	versions := make(map[int64][]string)
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(name), ".sql") || parser.IsCallbackFile(name) {
+			continue
+		}
+
+		if !nameRe.MatchString(name) {
+			issues = append(issues, Issue{File: name, Message: "file name doesn't match " + convention})
+
+			// Migrator skips files, which don't match explicit pattern, so they are not migrations.
+			if pattern != "" {
+				continue
+			}
+		}
+
+		prefix := strings.Split(name, "_")[0]
+		if prefix != "R" {
+			version, err := strconv.ParseInt(prefix, 10, 64)
+			if err != nil {
+				issues = append(issues, Issue{File: name, Message: fmt.Sprintf("can't parse version from %q", prefix)})
+			} else {
+				versions[version] = append(versions[version], name)
+			}
+		}
+
+		fileIssues, err := File(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, fileIssues...)
+	}
+
+	for version, files := range versions {
+		if len(files) > 1 {
+			for _, file := range files {
+				issues = append(issues, Issue{
+					File:    file,
+					Message: fmt.Sprintf("duplicate version %d in files %s", version, strings.Join(files, ", ")),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return issues[i].File < issues[j].File
+		}
+
+		return issues[i].Line < issues[j].Line
+	})
+
+	return issues, nil
+}
+
+// File checks content of one migration file.
+func File(path string) ([]Issue, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	name := filepath.Base(path)
+	issues := make([]Issue, 0)
+
+	parsed, err := parser.ParseMigration(f)
+	if err != nil {
+		return append(issues, Issue{File: name, Message: err.Error()}), nil
+	}
+
+	if _, err := f.Seek(0, 0); err != nil {
+		return nil, err
+	}
+
+	var (
+		upLine, downLine int
+		direction        string
+		statement        strings.Builder
+		statementLine    int
+		commented        bool
+		prevComment      bool
+	)
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case strings.HasPrefix(line, annotationPrefix+" Up"):
+			upLine, direction = lineNumber, "up"
+			continue
+		case strings.HasPrefix(line, annotationPrefix+" Down"):
+			downLine, direction = lineNumber, "down"
+			continue
+		case strings.HasPrefix(line, annotationPrefix):
+			continue
+		}
+
+		if direction != "up" {
+			continue
+		}
+
+		// Comment before or inside of statement explains it.
+		if strings.HasPrefix(line, "--") {
+			prevComment = true
+			commented = commented || statement.Len() > 0
+			continue
+		}
+
+		if line == "" {
+			continue
+		}
+
+		if statement.Len() == 0 {
+			statementLine = lineNumber
+			commented = prevComment
+		}
+		prevComment = false
+
+		statement.WriteString(line + " ")
+
+		if strings.HasSuffix(line, ";") {
+			issues = append(issues, checkStatement(name, statementLine, statement.String(), commented)...)
+			statement.Reset()
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if statement.Len() > 0 {
+		issues = append(issues, checkStatement(name, statementLine, statement.String(), commented)...)
+	}
+
+	if downLine > 0 && upLine > downLine {
+		issues = append(issues, Issue{File: name, Line: downLine, Message: "Down section before Up section"})
+	}
+
+	if !parsed.Repeatable && !strings.HasPrefix(name, "R_") {
+		switch {
+		case downLine == 0:
+			issues = append(issues, Issue{File: name, Message: "missing Down section"})
+		case strings.TrimSpace(parsed.DownStatements) == "":
+			issues = append(issues, Issue{File: name, Line: downLine, Message: "empty Down section"})
+		}
+	}
+
+	return issues, nil
+}
+
+func checkStatement(file string, line int, statement string, commented bool) []Issue {
+	if commented {
+		return nil
+	}
+
+	issues := make([]Issue, 0)
+	for _, dangerous := range dangerousStatements {
+		if dangerous.re.MatchString(statement) {
+			issues = append(issues, Issue{
+				File:    file,
+				Line:    line,
+				Message: fmt.Sprintf("dangerous statement %s without a comment", dangerous.name),
+			})
+		}
+	}
+
+	return issues
+}
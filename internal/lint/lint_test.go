@@ -0,0 +1,83 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"1_ok.sql": "-- +gomigrator Up\n" +
+			"-- Table is not used since v2.\n" +
+			"DROP TABLE old;\n" +
+			"-- +gomigrator Down\n" +
+			"CREATE TABLE old (id int);\n",
+		"2_no_down.sql":    "-- +gomigrator Up\nCREATE TABLE test (id int);\n",
+		"3_empty_down.sql": "-- +gomigrator Up\nCREATE TABLE test (id int);\n-- +gomigrator Down\n",
+		"4_down_first.sql": "-- +gomigrator Down\nDROP TABLE test;\n-- +gomigrator Up\nCREATE TABLE test (id int);\n",
+		"5_dangerous.sql": "-- +gomigrator Up\n" +
+			"ALTER TABLE test\n" +
+			"    ALTER COLUMN id TYPE bigint;\n" +
+			"-- +gomigrator Down\n" +
+			"ALTER TABLE test ALTER COLUMN id TYPE int;\n",
+		"6_dup.sql":          "-- +gomigrator Up\nSELECT 1;\n-- +gomigrator Down\nSELECT 1;\n",
+		"6_dup_again.sql":    "-- +gomigrator Up\nSELECT 1;\n-- +gomigrator Down\nSELECT 1;\n",
+		"abc_no_version.sql": "-- +gomigrator Up\nSELECT 1;\n-- +gomigrator Down\nSELECT 1;\n",
+		"7_BadName.sql":      "-- +gomigrator Up\nSELECT 1;\n-- +gomigrator Down\nSELECT 1;\n",
+		"R_views.sql":        "-- +gomigrator Up\nCREATE OR REPLACE VIEW v AS SELECT 1;\n",
+		"readme.md":          "not a migration",
+		"afterMigrate.sql":   "REFRESH MATERIALIZED VIEW stats;\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	issues, err := Dir(dir, "")
+	require.NoError(t, err)
+
+	messages := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		messages = append(messages, issue.String())
+	}
+
+	assert.Equal(t, []string{
+		"2_no_down.sql: missing Down section",
+		"3_empty_down.sql:3: empty Down section",
+		"4_down_first.sql:1: Down section before Up section",
+		"5_dangerous.sql:2: dangerous statement ALTER COLUMN TYPE without a comment",
+		"6_dup.sql: duplicate version 6 in files 6_dup.sql, 6_dup_again.sql",
+		"6_dup_again.sql: duplicate version 6 in files 6_dup.sql, 6_dup_again.sql",
+		"7_BadName.sql: file name doesn't match <version>_<name>.sql convention",
+		"abc_no_version.sql: file name doesn't match <version>_<name>.sql convention",
+		"abc_no_version.sql: can't parse version from \"abc\"",
+	}, messages)
+}
+
+func TestDirWithPattern(t *testing.T) {
+	dir := t.TempDir()
+	content := "-- +gomigrator Up\nSELECT 1;\n-- +gomigrator Down\nSELECT 1;\n"
+	for _, name := range []string{"1_CreateUsers.sql", "2_AddIndex.sql", "V3__users.sql", "schema.SQL"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	issues, err := Dir(dir, `^\d+_[A-Za-z]+\.sql$`)
+	require.NoError(t, err)
+
+	messages := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		messages = append(messages, issue.String())
+	}
+
+	assert.Equal(t, []string{
+		`V3__users.sql: file name doesn't match file pattern ^\d+_[A-Za-z]+\.sql$`,
+		`schema.SQL: file name doesn't match file pattern ^\d+_[A-Za-z]+\.sql$`,
+	}, messages)
+
+	_, err = Dir(dir, "(")
+	assert.Error(t, err)
+}
@@ -34,6 +34,7 @@ var logLevels = map[string]logLevel{
 type Logger struct {
 	level   logLevel
 	writeTo io.Writer
+	secrets []string
 }
 
 func New(level string, writeTo io.Writer) *Logger {
@@ -45,7 +46,16 @@ func New(level string, writeTo io.Writer) *Logger {
 		targetLvl = logLevels["debug"]
 	}
 
-	return &Logger{targetLvl, writeTo}
+	return &Logger{level: targetLvl, writeTo: writeTo}
+}
+
+// Redact hides secrets (passwords and etc.) in every log line.
+func (l *Logger) Redact(secrets ...string) {
+	for _, secret := range secrets {
+		if secret != "" {
+			l.secrets = append(l.secrets, secret)
+		}
+	}
 }
 
 func (l Logger) core(level logLevel, msg string, params ...any) {
@@ -63,7 +73,13 @@ func (l Logger) core(level logLevel, msg string, params ...any) {
 	}
 
 	builtString.WriteString("\n")
-	_, _ = l.writeTo.Write([]byte(builtString.String()))
+
+	line := builtString.String()
+	for _, secret := range l.secrets {
+		line = strings.ReplaceAll(line, secret, "xxxxx")
+	}
+
+	_, _ = l.writeTo.Write([]byte(line))
 }
 
 func (l Logger) Error(msg string, params ...any) {
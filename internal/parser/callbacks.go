@@ -0,0 +1,32 @@
+package parser
+
+import "slices"
+
+// Callback files in migrations dir, which are executed by up at the corresponding points.
+// Rollback of migrations (down and the first step of redo) doesn't run callbacks.
+const (
+	// Before the first migration (even if there is nothing to apply).
+	CallbackBeforeMigrate = "beforeMigrate.sql"
+
+	// Before each applied migration.
+	CallbackBeforeEachMigrate = "beforeEachMigrate.sql"
+
+	// After each successfully applied migration.
+	CallbackAfterEachMigrate = "afterEachMigrate.sql"
+
+	// After all migrations are successfully applied (even if there was nothing to apply).
+	CallbackAfterMigrate = "afterMigrate.sql"
+)
+
+// CallbackFiles are names of all callback files in order of execution.
+var CallbackFiles = []string{
+	CallbackBeforeMigrate,
+	CallbackBeforeEachMigrate,
+	CallbackAfterEachMigrate,
+	CallbackAfterMigrate,
+}
+
+// IsCallbackFile reports whether file of migrations dir is a callback, not a migration.
+func IsCallbackFile(name string) bool {
+	return slices.Contains(CallbackFiles, name)
+}
@@ -0,0 +1,97 @@
+package parser
+
+import "strings"
+
+// SplitStatements splits SQL script into statements by semicolons,
+// which are not inside of quotes or comments. Empty statements are skipped.
+// Statement between "-- +gomigrator StatementBegin" and "-- +gomigrator StatementEnd"
+// isn't split (e.g. CREATE PROCEDURE with body).
+func SplitStatements(script string) []string {
+	statements := make([]string, 0)
+
+	var (
+		b       strings.Builder
+		quote   rune
+		inBlock bool
+	)
+
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		switch {
+		case quote != 0:
+			// Quote is escaped by backslash or doubled.
+			if r == '\\' && quote != '`' && next != 0 {
+				b.WriteRune(r)
+				b.WriteRune(next)
+				i++
+				continue
+			}
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '-' && next == '-', r == '#':
+			// Line comment.
+			end := i
+			for end < len(runes) && runes[end] != '\n' {
+				end++
+			}
+			comment := string(runes[i:end])
+			i = end - 1
+
+			switch strings.TrimSpace(comment) {
+			case statementBegin:
+				statements = appendStatement(statements, b.String())
+				b.Reset()
+				inBlock = true
+			case statementEnd:
+				// Block is a single statement, so its trailing semicolon is dropped.
+				statements = appendStatement(statements, strings.TrimSuffix(strings.TrimSpace(b.String()), ";"))
+				b.Reset()
+				inBlock = false
+			default:
+				b.WriteString(comment)
+			}
+			continue
+		case r == '/' && next == '*':
+			// Block comment.
+			end := i + 2
+			for end < len(runes) && (runes[end-1] != '*' || runes[end] != '/' || end == i+2) {
+				end++
+			}
+			end = min(end+1, len(runes))
+			b.WriteString(string(runes[i:end]))
+			i = end - 1
+			continue
+		case r == ';' && !inBlock:
+			statements = appendStatement(statements, b.String())
+			b.Reset()
+			continue
+		}
+
+		b.WriteRune(r)
+	}
+
+	return appendStatement(statements, b.String())
+}
+
+// Append statement, if it contains anything but comments.
+func appendStatement(statements []string, statement string) []string {
+	statement = strings.TrimSpace(statement)
+
+	for _, line := range strings.Split(statement, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") && !strings.HasPrefix(line, "#") {
+			return append(statements, statement)
+		}
+	}
+
+	return statements
+}
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- Users.
+CREATE TABLE users (id INT, name VARCHAR(10) DEFAULT 'a;b');
+INSERT INTO users VALUES (1, 'it''s; fine'), (2, "x\";y");
+/* comment; with semicolon */
+ALTER TABLE users ADD COLUMN ` + "`weird;name`" + ` INT; # trailing; comment
+-- only comment;
+`
+
+	assert.Equal(t, []string{
+		"-- Users.\nCREATE TABLE users (id INT, name VARCHAR(10) DEFAULT 'a;b')",
+		`INSERT INTO users VALUES (1, 'it''s; fine'), (2, "x\";y")`,
+		"/* comment; with semicolon */\nALTER TABLE users ADD COLUMN `weird;name` INT",
+	}, SplitStatements(script))
+
+	assert.Empty(t, SplitStatements("  \n-- nothing\n"))
+}
+
+func TestSplitStatementBlocks(t *testing.T) {
+	script := `CREATE TABLE counters (n INT);
+-- +gomigrator StatementBegin
+CREATE PROCEDURE increment()
+BEGIN
+    UPDATE counters SET n = n + 1;
+    SELECT n FROM counters;
+END;
+-- +gomigrator StatementEnd
+-- +gomigrator StatementBegin
+CREATE TRIGGER counters_check BEFORE UPDATE ON counters FOR EACH ROW
+BEGIN
+    IF NEW.n < 0 THEN SET NEW.n = 0; END IF;
+END
+-- +gomigrator StatementEnd
+DROP TABLE old;
+`
+
+	assert.Equal(t, []string{
+		"CREATE TABLE counters (n INT)",
+		"CREATE PROCEDURE increment()\nBEGIN\n    UPDATE counters SET n = n + 1;\n    SELECT n FROM counters;\nEND",
+		"CREATE TRIGGER counters_check BEFORE UPDATE ON counters FOR EACH ROW\n" +
+			"BEGIN\n    IF NEW.n < 0 THEN SET NEW.n = 0; END IF;\nEND",
+		"DROP TABLE old",
+	}, SplitStatements(script))
+}
+
+func TestSplitParsedMigration(t *testing.T) {
+	content := `-- +gomigrator Up
+-- +gomigrator StatementBegin
+CREATE PROCEDURE increment()
+BEGIN
+    UPDATE counters SET n = n + 1;
+END;
+-- +gomigrator StatementEnd
+-- +gomigrator Down
+DROP PROCEDURE increment;
+`
+
+	// Annotations of statements are kept in parsed migration.
+	parsed, err := ParseMigration(strings.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"CREATE PROCEDURE increment()\nBEGIN\n    UPDATE counters SET n = n + 1;\nEND",
+	}, SplitStatements(parsed.UpStatements))
+	assert.Equal(t, []string{"DROP PROCEDURE increment"}, SplitStatements(parsed.DownStatements))
+}
@@ -4,17 +4,37 @@ import (
 	"bufio"
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
+	"strconv"
 	"strings"
 )
 
 type ParsedMigration struct {
 	UpStatements   string
 	DownStatements string
+
+	// Repeatable is set by "-- +gomigrator Repeatable" annotation.
+	Repeatable bool
+
+	// Environments from "-- +gomigrator Env: dev, test" annotation (used by seeds).
+	Environments []string
+
+	// Versions from "-- +gomigrator Depends: 20250302201917, 20250302211917" annotation.
+	Depends []int64
+
+	// Versions of squashed migrations from "-- +gomigrator Baseline: 1, 2, 3" annotation.
+	Baseline []int64
 }
 
 var prefix = "-- +gomigrator"
 
+// Annotations of statement with semicolons inside (e.g. body of procedure or trigger).
+var (
+	statementBegin = prefix + " StatementBegin"
+	statementEnd   = prefix + " StatementEnd"
+)
+
 var ErrIncorrectTemplate = errors.New("incorrect sql-migration template")
 
 func ParseMigration(r io.ReadSeeker) (*ParsedMigration, error) {
@@ -34,6 +54,34 @@ func ParseMigration(r io.ReadSeeker) (*ParsedMigration, error) {
 	for scanner.Scan() {
 		line := scanner.Text()
 
+		if strings.HasPrefix(line, prefix+" Repeatable") {
+			p.Repeatable = true
+			continue
+		}
+
+		if strings.HasPrefix(line, prefix+" Env:") {
+			for _, env := range strings.Split(strings.TrimPrefix(line, prefix+" Env:"), ",") {
+				if env = strings.TrimSpace(env); env != "" {
+					p.Environments = append(p.Environments, env)
+				}
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, prefix+" Depends:") {
+			if p.Depends, err = parseVersions(strings.TrimPrefix(line, prefix+" Depends:")); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, prefix+" Baseline:") {
+			if p.Baseline, err = parseVersions(strings.TrimPrefix(line, prefix+" Baseline:")); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
 		if strings.HasPrefix(line, prefix+" Up") {
 			direction = "up"
 		}
@@ -51,7 +99,8 @@ func ParseMigration(r io.ReadSeeker) (*ParsedMigration, error) {
 			return nil, ErrIncorrectTemplate
 		}
 
-		if !strings.HasPrefix(line, "-- +") {
+		// Statement annotations are kept for drivers, which split migration into statements.
+		if !strings.HasPrefix(line, "-- +") || isStatementAnnotation(line) {
 			if _, err := buf.WriteString(line + "\n"); err != nil {
 				return nil, err
 			}
@@ -67,3 +116,60 @@ func ParseMigration(r io.ReadSeeker) (*ParsedMigration, error) {
 
 	return p, nil
 }
+
+func isStatementAnnotation(line string) bool {
+	line = strings.TrimSpace(line)
+
+	return line == statementBegin || line == statementEnd
+}
+
+// ReplaceVersions replaces versions in Depends and Baseline annotations of migration content.
+// Versions, which are absent in replacements, are kept as is.
+func ReplaceVersions(content string, replacements map[int64]string) (string, error) {
+	lines := strings.SplitAfter(content, "\n")
+
+	for i, line := range lines {
+		for _, annotation := range []string{prefix + " Depends:", prefix + " Baseline:"} {
+			if !strings.HasPrefix(line, annotation) {
+				continue
+			}
+
+			versions, err := parseVersions(strings.TrimPrefix(line, annotation))
+			if err != nil {
+				return "", err
+			}
+
+			items := make([]string, 0, len(versions))
+			for _, version := range versions {
+				if replacement, ok := replacements[version]; ok {
+					items = append(items, replacement)
+				} else {
+					items = append(items, strconv.FormatInt(version, 10))
+				}
+			}
+
+			ending := line[len(strings.TrimRight(line, "\r\n")):]
+			lines[i] = annotation + " " + strings.Join(items, ", ") + ending
+		}
+	}
+
+	return strings.Join(lines, ""), nil
+}
+
+// Parse comma separated list of versions.
+func parseVersions(list string) ([]int64, error) {
+	versions := make([]int64, 0)
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+
+		version, err := strconv.ParseInt(item, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid version %q", ErrIncorrectTemplate, item)
+		}
+		versions = append(versions, version)
+	}
+
+	return versions, nil
+}
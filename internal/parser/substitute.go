@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var ErrUndefinedVariable = errors.New("undefined variable")
+
+// Placeholders "${name}" and "{{ .Name }}".
+var placeholderRe = regexp.MustCompile(`\$\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}|\{\{\s*\.([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Substitute replaces placeholders "${name}" and "{{ .Name }}" by values of variables.
+// Names are matched by VariableName, if there is no variable with exactly the same name.
+// Undefined placeholders are kept as is, or reported by ErrUndefinedVariable in strict mode.
+func Substitute(script string, variables map[string]string, strict bool) (string, error) {
+	undefined := make(map[string]bool)
+
+	result := placeholderRe.ReplaceAllStringFunc(script, func(placeholder string) string {
+		match := placeholderRe.FindStringSubmatch(placeholder)
+
+		name := match[1]
+		if name == "" {
+			name = match[2]
+		}
+
+		if value, ok := lookupVariable(variables, name); ok {
+			return value
+		}
+
+		undefined[name] = true
+
+		return placeholder
+	})
+
+	if strict && len(undefined) > 0 {
+		names := make([]string, 0, len(undefined))
+		for name := range undefined {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return "", fmt.Errorf("%w: %s", ErrUndefinedVariable, strings.Join(names, ", "))
+	}
+
+	return result, nil
+}
+
+// VariableName returns normalized name of variable: names are compared in lower case
+// without underscores, so app_user, APP_USER and AppUser are the same variable.
+func VariableName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+func lookupVariable(variables map[string]string, name string) (string, bool) {
+	if value, ok := variables[name]; ok {
+		return value, true
+	}
+
+	for key, value := range variables {
+		if VariableName(key) == VariableName(name) {
+			return value, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,39 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubstitute(t *testing.T) {
+	variables := map[string]string{
+		"schema":   "billing",
+		"app_user": "billing_app",
+	}
+
+	script := "CREATE TABLE ${schema}.users (id int);\n" +
+		"GRANT SELECT ON ${ schema }.users TO {{ .AppUser }}, ${APP_USER}, ${appuser};\n" +
+		"SELECT '${missing}', '{{.Other}}';\n"
+
+	result, err := Substitute(script, variables, false)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		"CREATE TABLE billing.users (id int);\n"+
+			"GRANT SELECT ON billing.users TO billing_app, billing_app, billing_app;\n"+
+			"SELECT '${missing}', '{{.Other}}';\n",
+		result,
+	)
+
+	_, err = Substitute(script, variables, true)
+	require.ErrorIs(t, err, ErrUndefinedVariable)
+	assert.EqualError(t, err, "undefined variable: Other, missing")
+
+	// Dollar quotes and JSON aren't placeholders.
+	script = "CREATE FUNCTION f() RETURNS int AS $$ SELECT 1 $$ LANGUAGE sql;\nSELECT '{\"a\": {\"b\": 1}}';\n"
+	result, err = Substitute(script, nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, script, result)
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/EvgenyRomanov/sql-migrator/internal/parser"
+)
+
+// Callback files in migrations dir, which are executed by up at the corresponding points
+// (redo runs only callbacks of each migration for the re-applied one, down doesn't run them).
+// Every callback and migration is run separately (in its own transaction and maybe on another connection
+// of pool), so session settings (e.g. SET ROLE) of callback don't apply to migrations.
+const (
+	CallbackBeforeMigrate     = parser.CallbackBeforeMigrate
+	CallbackBeforeEachMigrate = parser.CallbackBeforeEachMigrate
+	CallbackAfterEachMigrate  = parser.CallbackAfterEachMigrate
+	CallbackAfterMigrate      = parser.CallbackAfterMigrate
+)
+
+// IsCallbackFile reports whether file of migrations dir is a callback, not a migration.
+func IsCallbackFile(name string) bool {
+	return parser.IsCallbackFile(name)
+}
+
+// Names of callback files, which are run by migrator.
+func (m *Migrate) callbacks() []string {
+	if m.skipCallbacks {
+		return nil
+	}
+
+	return parser.CallbackFiles
+}
+
+// Execute callback file, if it exists.
+func (m *Migrate) runCallback(name string) error {
+	if m.skipCallbacks {
+		return nil
+	}
+
+	content, err := fs.ReadFile(m.files(), name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("can't read callback %s: %w", name, err)
+	}
+
+	if strings.TrimSpace(string(content)) == "" {
+		return nil
+	}
+
+	script, err := m.substitute(name, string(content))
+	if err != nil {
+		return err
+	}
+
+	if err := m.driver.Run(strings.NewReader(script)); err != nil {
+		return fmt.Errorf("can't execute callback %s: %w", name, err)
+	}
+
+	return nil
+}
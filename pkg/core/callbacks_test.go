@@ -0,0 +1,105 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallbacks(t *testing.T) {
+	db := memory.Get(t.Name())
+	db.Reset()
+
+	fsys := fstest.MapFS{
+		"1_users.sql":             {Data: []byte(migrationContent("CREATE TABLE users;", "DROP TABLE users;"))},
+		"2_orders.sql":            {Data: []byte(migrationContent("CREATE TABLE orders;", "DROP TABLE orders;"))},
+		CallbackBeforeMigrate:     {Data: []byte("CREATE EXTENSION IF NOT EXISTS pgcrypto;")},
+		CallbackAfterEachMigrate:  {Data: []byte("GRANT SELECT ON ALL TABLES IN SCHEMA public TO reader;")},
+		CallbackAfterMigrate:      {Data: []byte("REFRESH MATERIALIZED VIEW stats;")},
+		CallbackBeforeEachMigrate: {Data: []byte("  \n")},
+	}
+
+	migrator, err := Open(WithDSN(memory.DSN(t.Name())), WithFS(fsys), WithFilePattern(`\.sql$`))
+	require.NoError(t, err)
+
+	require.NoError(t, migrator.Up())
+	assert.Equal(t, []string{
+		"CREATE EXTENSION IF NOT EXISTS pgcrypto;",
+		"CREATE TABLE users;\n",
+		"GRANT SELECT ON ALL TABLES IN SCHEMA public TO reader;",
+		"CREATE TABLE orders;\n",
+		"GRANT SELECT ON ALL TABLES IN SCHEMA public TO reader;",
+		"REFRESH MATERIALIZED VIEW stats;",
+	}, db.Executed())
+
+	// Callbacks run even if there is nothing to apply, rollback doesn't run them.
+	require.ErrorIs(t, migrator.Up(), ErrAlreadyUpToDate)
+	require.NoError(t, migrator.Down())
+	assert.Equal(t, []string{
+		"CREATE EXTENSION IF NOT EXISTS pgcrypto;",
+		"REFRESH MATERIALIZED VIEW stats;",
+		"DROP TABLE orders;\n",
+	}, db.Executed()[6:])
+
+	// Only re-applied migration of redo runs callbacks of each migration.
+	require.NoError(t, migrator.Up())
+	require.NoError(t, migrator.Redo())
+	assert.Equal(t, []string{
+		"DROP TABLE orders;\n",
+		"CREATE TABLE orders;\n",
+		"GRANT SELECT ON ALL TABLES IN SCHEMA public TO reader;",
+	}, db.Executed()[len(db.Executed())-3:])
+
+	// Failed callback fails up.
+	db.FailOnSQL("REFRESH", errors.New("permission denied"))
+	require.ErrorContains(t, migrator.Up(), "can't execute callback afterMigrate.sql: permission denied")
+	assert.Len(t, db.Versions("migrations"), 2)
+}
+
+func TestAfterEachCallbackFailure(t *testing.T) {
+	db := memory.Get(t.Name())
+	db.Reset()
+	db.FailOnSQL("GRANT", errors.New("permission denied"))
+
+	fsys := fstest.MapFS{
+		"1_users.sql":            {Data: []byte(migrationContent("CREATE TABLE users;", "DROP TABLE users;"))},
+		CallbackAfterEachMigrate: {Data: []byte("GRANT SELECT ON users TO reader;")},
+	}
+	observer := &recordingObserver{}
+
+	migrator, err := Open(WithDSN(memory.DSN(t.Name())), WithFS(fsys), WithHooks(observer))
+	require.NoError(t, err)
+
+	// Migration is recorded as applied, only the callback fails.
+	require.ErrorContains(t, migrator.Up(), "can't execute callback afterEachMigrate.sql: permission denied")
+	assert.Equal(t, []int64{1}, appliedVersions(db))
+	assert.Contains(t, observer.events, "after up 1_users.sql: <nil>")
+	require.Len(t, observer.runs, 1)
+	assert.Len(t, observer.runs[0].Migrations, 1)
+	assert.Error(t, observer.runs[0].Err)
+}
+
+func TestDriftSkipsCallbacks(t *testing.T) {
+	target := t.Name() + "_target"
+	scratch := t.Name() + "_scratch"
+	memory.Get(target).Reset()
+	memory.Get(scratch).Reset()
+
+	fsys := fstest.MapFS{
+		"1_users.sql":         {Data: []byte(migrationContent("CREATE TABLE users;", "DROP TABLE users;"))},
+		CallbackBeforeMigrate: {Data: []byte("CREATE EXTENSION IF NOT EXISTS pgcrypto;")},
+		CallbackAfterMigrate:  {Data: []byte("REFRESH MATERIALIZED VIEW stats;")},
+	}
+
+	migrator, err := Open(WithDSN("introspected://"+target), WithFS(fsys))
+	require.NoError(t, err)
+
+	_, err = migrator.Drift("introspected://" + scratch)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CREATE TABLE users;\n"}, memory.Get(scratch).Executed())
+	assert.Empty(t, memory.Get(target).Executed())
+}
@@ -0,0 +1,122 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database"
+	"github.com/EvgenyRomanov/sql-migrator/pkg/schema"
+)
+
+// Diff compares two schemas and returns SQL statements to change schema from the first one
+// to the second one (up) and back (down).
+// From and to are DSNs of databases or names of schemas of database set by options
+// (WithDSN, WithDriver or WithDB), which is opened only if one of them is a schema.
+// If one of them is a schema, the other DSN is compared by objects of DefaultSchema.
+// Unlike Open, Diff doesn't create migrations table.
+func Diff(from string, to string, opts ...Option) (up []string, down []string, err error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	target := func() (*schema.Schema, error) {
+		driver, err := o.openDriver()
+		if err != nil {
+			return nil, err
+		}
+		defer o.closeDriver(driver)
+
+		return introspect(driver, o.seedsTableName)
+	}
+
+	return diff(from, to, o.tableName, o.seedsTableName, target)
+}
+
+// Diff is Diff for schemas of migrator database.
+func (m *Migrate) Diff(from string, to string) (up []string, down []string, err error) {
+	return diff(from, to, m.tableName, m.seedsTable, m.Schema)
+}
+
+// Compare schemas, target reads schema of database, which schemas are compared by name.
+func diff(
+	from string,
+	to string,
+	tableName string,
+	seedsTableName string,
+	target func() (*schema.Schema, error),
+) ([]string, []string, error) {
+	// Target database is read once, even if both sides are its schemas.
+	var targetSchema *schema.Schema
+	readTarget := func() (*schema.Schema, error) {
+		if targetSchema != nil {
+			return targetSchema, nil
+		}
+
+		s, err := target()
+		if err != nil {
+			return nil, err
+		}
+		targetSchema = s
+
+		return s, nil
+	}
+
+	fromSchema, fromName, err := sourceSchema(from, tableName, seedsTableName, readTarget)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	toSchema, toName, err := sourceSchema(to, tableName, seedsTableName, readTarget)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Statements should use names of schema, which is changed.
+	if fromName != "" || toName != "" {
+		if fromName == "" {
+			fromName = DefaultSchema
+			fromSchema = fromSchema.Filter(DefaultSchema)
+		}
+
+		if toName == "" {
+			toName = DefaultSchema
+			toSchema = toSchema.Filter(DefaultSchema)
+		}
+
+		toSchema.RenameSchema(toName, fromName)
+	}
+
+	return schema.Changes(fromSchema, toSchema), schema.Changes(toSchema, fromSchema), nil
+}
+
+// Read schema of database by DSN or schema of target database by name.
+// Name of schema is empty for DSN.
+func sourceSchema(
+	source string,
+	tableName string,
+	seedsTableName string,
+	target func() (*schema.Schema, error),
+) (*schema.Schema, string, error) {
+	if !strings.Contains(source, "://") {
+		s, err := target()
+		if err != nil {
+			return nil, "", err
+		}
+
+		return s.Filter(source), source, nil
+	}
+
+	driver, err := database.Open(source, tableName)
+	if err != nil {
+		return nil, "", fmt.Errorf("can't open database: %w", database.RedactError(err, source))
+	}
+	defer driver.Close()
+
+	s, err := introspect(driver, seedsTableName)
+	if err != nil {
+		return nil, "", database.RedactError(err, source)
+	}
+
+	return s, "", nil
+}
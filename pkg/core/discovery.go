@@ -0,0 +1,287 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EvgenyRomanov/sql-migrator/internal/parser"
+)
+
+// DefaultFilePattern matches versioned (<version>_<name>.sql) and repeatable (R_<name>.sql) migration files.
+const DefaultFilePattern = `^(\d+|R)_.+\.sql$`
+
+var ErrInvalidMigrations = errors.New("invalid migration files")
+
+var defaultFilePattern = regexp.MustCompile(DefaultFilePattern)
+
+// Discover returns validated migrations from dir: versioned ones sorted by version followed
+// by repeatable ones sorted by name. Files, which names don't match pattern, are ignored.
+// If pattern is empty, DefaultFilePattern is used and .sql files, which don't match it, are errors.
+func Discover(dir string, pattern string) (Migrations, error) {
+	m := &Migrate{dir: dir}
+	if err := m.SetFilePattern(pattern); err != nil {
+		return nil, err
+	}
+
+	return m.AvailableMigrations()
+}
+
+// SetFilePattern sets regular expression for names of migration files.
+// Other files in migrations dir are ignored. With empty pattern DefaultFilePattern is used
+// and misnamed .sql files aren't ignored, they make migrations invalid.
+func (m *Migrate) SetFilePattern(pattern string) error {
+	if pattern == "" {
+		m.filePattern = nil
+		return nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid migration file pattern: %w", err)
+	}
+
+	m.filePattern = re
+
+	return nil
+}
+
+// AvailableMigrations returns validated migrations from migrations dir: versioned ones
+// sorted by version followed by repeatable ones sorted by name.
+func (m *Migrate) AvailableMigrations() (Migrations, error) {
+	all, err := m.readMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	versioned, repeatable := splitMigrations(all)
+
+	return append(versioned, repeatable...), nil
+}
+
+// Get available versioned migrations sorted by version.
+func (m *Migrate) findAvailableMigrations() (Migrations, error) {
+	all, err := m.readMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	versioned, _ := splitMigrations(all)
+
+	return versioned, nil
+}
+
+// Get available repeatable migrations sorted by name.
+func (m *Migrate) findRepeatableMigrations() (Migrations, error) {
+	all, err := m.readMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	_, repeatable := splitMigrations(all)
+
+	return repeatable, nil
+}
+
+// Split migrations to versioned (sorted by version) and repeatable (sorted by name).
+func splitMigrations(all Migrations) (Migrations, Migrations) {
+	versioned := make(Migrations, 0)
+	repeatable := make(Migrations, 0)
+
+	for _, migration := range all {
+		if migration.Repeatable {
+			repeatable = append(repeatable, migration)
+		} else {
+			versioned = append(versioned, migration)
+		}
+	}
+
+	// Insure then they are sorted by version correctly.
+	sort.Slice(versioned, func(i, j int) bool {
+		return versioned[i].Version < versioned[j].Version
+	})
+
+	sort.Slice(repeatable, func(i, j int) bool {
+		return repeatable[i].Source < repeatable[j].Source
+	})
+
+	return versioned, repeatable
+}
+
+// Read migration files from dir and validate their versions.
+func (m *Migrate) readMigrations() (Migrations, error) {
+	pattern := m.filePattern
+	if pattern == nil {
+		pattern = defaultFilePattern
+	}
+
+	migrations, err := m.readFiles(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	problems := make([]string, 0)
+	sources := make(map[int64][]string)
+
+	// Misnamed migration is an error, silent skipping is enabled by explicit pattern only.
+	if m.filePattern == nil {
+		misnamed, err := m.misnamedFiles()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range misnamed {
+			problems = append(problems, fmt.Sprintf("file %s doesn't match pattern %s", name, DefaultFilePattern))
+		}
+	}
+
+	for _, migration := range migrations {
+		if migration.Repeatable {
+			continue
+		}
+
+		version, err := m.getVersionFromFileName(migration.Source)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+
+		migration.Version = version
+		sources[version] = append(sources[version], migration.Source)
+	}
+
+	for version, files := range sources {
+		if len(files) > 1 {
+			sort.Strings(files)
+			problems = append(problems, fmt.Sprintf("version %d is used by files %s", version, strings.Join(files, ", ")))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidMigrations, strings.Join(problems, "; "))
+	}
+
+	return migrations, nil
+}
+
+// Read and parse all files from dir, which names match pattern.
+func (m *Migrate) readFiles(pattern *regexp.Regexp) (Migrations, error) {
+	migrations := make([]*Migration, 0)
+
+	entries, err := fs.ReadDir(m.files(), ".")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || IsCallbackFile(entry.Name()) || !pattern.MatchString(entry.Name()) {
+			continue
+		}
+
+		migration, err := m.parseSQLMigration(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, migration)
+	}
+
+	return migrations, nil
+}
+
+// Get names of .sql files, which don't match DefaultFilePattern. Callbacks and schema snapshot are not migrations.
+func (m *Migrate) misnamedFiles() ([]string, error) {
+	entries, err := fs.ReadDir(m.files(), ".")
+	if err != nil {
+		return nil, err
+	}
+
+	misnamed := make([]string, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(name), ".sql") || IsCallbackFile(name) ||
+			defaultFilePattern.MatchString(name) || m.isSchemaFile(name) {
+			continue
+		}
+
+		misnamed = append(misnamed, name)
+	}
+
+	return misnamed, nil
+}
+
+// Check if file from migrations dir is SchemaFile.
+func (m *Migrate) isSchemaFile(name string) bool {
+	if m.SchemaFile == "" || m.fsys != nil {
+		return false
+	}
+
+	return filepath.Clean(filepath.Join(m.dir, name)) == filepath.Clean(m.SchemaFile)
+}
+
+// Parse SQL migration file.
+func (m *Migrate) parseSQLMigration(name string) (*Migration, error) {
+	content, err := fs.ReadFile(m.files(), name)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening %s: %w", name, err)
+	}
+
+	migration := &Migration{
+		Source: name,
+	}
+
+	parsed, err := parser.ParseMigration(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing file %s: %w", name, err)
+	}
+
+	// Set statements.
+	migration.UpSQL = parsed.UpStatements
+	migration.DownSQL = parsed.DownStatements
+	migration.Environments = parsed.Environments
+	migration.Depends = parsed.Depends
+	migration.Baseline = parsed.Baseline
+
+	// Repeatable migrations are identified by file name, not by version.
+	migration.Repeatable = parsed.Repeatable || strings.HasPrefix(name, RepeatablePrefix)
+
+	checksum := sha256.Sum256([]byte(migration.UpSQL))
+	migration.Checksum = hex.EncodeToString(checksum[:])
+
+	return migration, nil
+}
+
+// File system with migration files.
+func (m *Migrate) files() fs.FS {
+	if m.fsys != nil {
+		return m.fsys
+	}
+
+	dir := m.dir
+	if dir == "" {
+		dir = "."
+	}
+
+	return os.DirFS(dir)
+}
+
+func (m *Migrate) getVersionFromFileName(filename string) (int64, error) {
+	version := strings.Split(filename, "_")[0]
+
+	i, err := strconv.ParseInt(version, 10, 64)
+	if err != nil || i < 0 {
+		return -1, fmt.Errorf("can't parse version from file name %s", filename)
+	}
+
+	return i, nil
+}
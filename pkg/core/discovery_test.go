@@ -0,0 +1,93 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMigrationContent = "-- +gomigrator Up\nSELECT 1;\n-- +gomigrator Down\nSELECT 1;\n"
+
+func writeMigrations(t *testing.T, names ...string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for _, name := range names {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(testMigrationContent), 0o600))
+	}
+
+	return dir
+}
+
+func TestDiscover(t *testing.T) {
+	dir := writeMigrations(t, "2_second.sql", "1_first.sql", "R_views.sql", "afterMigrate.sql", "notes.txt")
+
+	migrations, err := Discover(dir, "")
+	require.NoError(t, err)
+
+	sources := make([]string, 0, len(migrations))
+	for _, migration := range migrations {
+		sources = append(sources, migration.Source)
+	}
+	assert.Equal(t, []string{"1_first.sql", "2_second.sql", "R_views.sql"}, sources)
+}
+
+func TestDiscoverErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		pattern  string
+		expected string
+	}{
+		{
+			name:     "duplicate versions",
+			files:    []string{"1_first.sql", "1_first_again.sql", "2_second.sql"},
+			expected: "version 1 is used by files 1_first.sql, 1_first_again.sql",
+		},
+		{
+			name:     "invalid version",
+			files:    []string{"1_first.sql", "first.sql"},
+			pattern:  `\.sql$`,
+			expected: "can't parse version from file name first.sql",
+		},
+		{
+			name:     "misnamed files",
+			files:    []string{"1_first.sql", "2025_01_users.SQL", "V3__users.sql"},
+			expected: "file 2025_01_users.SQL doesn't match pattern ^(\\d+|R)_.+\\.sql$; file V3__users.sql doesn't match",
+		},
+		{
+			name:     "invalid pattern",
+			files:    []string{"1_first.sql"},
+			pattern:  `(`,
+			expected: "invalid migration file pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Discover(writeMigrations(t, tt.files...), tt.pattern)
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestDiscoverSkipsByExplicitPattern(t *testing.T) {
+	dir := writeMigrations(t, "1_first.sql", "2025_01_users.SQL", "V3__users.sql")
+
+	migrations, err := Discover(dir, DefaultFilePattern)
+	require.NoError(t, err)
+	require.Len(t, migrations, 1)
+	assert.Equal(t, "1_first.sql", migrations[0].Source)
+}
+
+func TestDiscoverSkipsSchemaFile(t *testing.T) {
+	dir := writeMigrations(t, "1_first.sql", "schema.sql")
+
+	m := &Migrate{dir: dir, SchemaFile: filepath.Join(dir, "schema.sql")}
+	migrations, err := m.AvailableMigrations()
+	require.NoError(t, err)
+	assert.Len(t, migrations, 1)
+}
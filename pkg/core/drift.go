@@ -0,0 +1,155 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/EvgenyRomanov/sql-migrator/internal/parser"
+	"github.com/EvgenyRomanov/sql-migrator/pkg/schema"
+)
+
+// DefaultSchema is schema of objects created by migrations without explicit schema.
+const DefaultSchema = "public"
+
+// Prefix of temporary schema created by Drift.
+const scratchSchemaPrefix = "gomigrator_drift_"
+
+var (
+	ErrDriftDetected      = errors.New("schema drift detected")
+	ErrScratchDSNRequired = errors.New("DSN of scratch database is required")
+	ErrInvalidSchemaName  = errors.New("invalid scratch schema name")
+)
+
+// Unquoted identifier, so name of scratch schema can be used in statements as is.
+var schemaNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}$`)
+
+// Drift applies all migrations to scratch database and compares its schema with schema of target database.
+// Scratch is DSN of disposable database, all its objects are compared.
+func (m *Migrate) Drift(scratch string) ([]schema.Difference, error) {
+	if !strings.Contains(scratch, "://") {
+		return nil, ErrScratchDSNRequired
+	}
+
+	actual, err := m.Schema()
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := m.migratedSchema(scratch, m.variables)
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.Compare(expected, actual), nil
+}
+
+// DriftInSchema is Drift, which applies migrations to schema created in target database
+// and dropped after the check (temporary schema is used if name is empty).
+// Only objects of DefaultSchema are compared.
+//
+// Migrations are isolated only by search_path, so objects with explicit schema, data and grants
+// of migrations are changed in target database. Use it only for databases, where it is acceptable.
+func (m *Migrate) DriftInSchema(name string) ([]schema.Difference, error) {
+	if name == "" {
+		name = fmt.Sprintf("%s%d", scratchSchemaPrefix, time.Now().UnixNano())
+	}
+
+	if !schemaNameRe.MatchString(name) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSchemaName, name)
+	}
+
+	actual, err := m.Schema()
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := m.migratedScratchSchema(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.Compare(expected, actual.Filter(DefaultSchema)), nil
+}
+
+// Apply migrations to schema created in target database and read the result.
+func (m *Migrate) migratedScratchSchema(name string) (*schema.Schema, error) {
+	dsn, err := withSearchPath(m.dsn, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.driver.Run(strings.NewReader(fmt.Sprintf("CREATE SCHEMA %s;", name))); err != nil {
+		return nil, fmt.Errorf("can't create scratch schema: %w", err)
+	}
+	defer m.driver.Run(strings.NewReader(fmt.Sprintf("DROP SCHEMA %s CASCADE;", name)))
+
+	// Placeholder of schema refers to scratch schema, not to the real one.
+	variables := make(map[string]string, len(m.variables)+1)
+	for key, value := range m.variables {
+		if parser.VariableName(key) != "schema" {
+			variables[key] = value
+		}
+	}
+	variables["schema"] = name
+
+	s, err := m.migratedSchema(dsn, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	s = s.Filter(name)
+	s.RenameSchema(name, DefaultSchema)
+
+	return s, nil
+}
+
+// Apply all migrations to scratch database with values of placeholders and read its schema.
+func (m *Migrate) migratedSchema(dsn string, variables map[string]string) (*schema.Schema, error) {
+	// Migrations table is created in scratch database (or schema) too.
+	tableName := m.tableName
+	if i := strings.LastIndex(tableName, "."); i >= 0 {
+		tableName = tableName[i+1:]
+	}
+
+	scratch, err := Open(
+		WithDSN(dsn),
+		WithTableName(tableName),
+		WithFS(m.files()),
+		WithVariables(variables),
+		WithStrictVariables(m.strictVariables),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("can't open scratch database: %w", err)
+	}
+	defer scratch.Close()
+
+	scratch.filePattern = m.filePattern
+
+	// Callbacks change environment of target database (roles, grants, views), not schema.
+	scratch.skipCallbacks = true
+
+	err = scratch.Up()
+	if err != nil && !errors.Is(err, ErrAlreadyUpToDate) && !errors.Is(err, ErrNoAvailableMigrations) {
+		return nil, fmt.Errorf("can't apply migrations to scratch database: %w", err)
+	}
+
+	return scratch.Schema()
+}
+
+// Set search_path of connection, so objects without explicit schema are created in the given one.
+func withSearchPath(dsn string, schemaName string) (string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme == "" {
+		return "", fmt.Errorf("can't use scratch schema: dsn should be URL")
+	}
+
+	query := u.Query()
+	query.Set("search_path", schemaName)
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/EvgenyRomanov/sql-migrator/internal/parser"
+)
+
+// SequentialVersionFormat is format of sequential versions (0001, 0002, ...).
+const SequentialVersionFormat = "%04d"
+
+// Versions starting from this value are considered timestamps (e.g. 1742241224843 or 20250302201917).
+const timestampVersionThreshold = 1_000_000_000
+
+var (
+	ErrNothingToFix   = errors.New("nothing to fix")
+	ErrFixUnsupported = errors.New("migration files can be renamed only in directory, not in fs.FS")
+)
+
+// Rename of migration file made by Fix.
+type Rename struct {
+	From string
+	To   string
+}
+
+// IsTimestampVersion reports whether version is a timestamp, not a sequential number.
+func IsTimestampVersion(version int64) bool {
+	return version >= timestampVersionThreshold
+}
+
+// NextSequentialVersion returns version following the last sequential version of migrations.
+func NextSequentialVersion(migrations Migrations) int64 {
+	var last int64
+	for _, migration := range migrations {
+		if !migration.Repeatable && !IsTimestampVersion(migration.Version) && migration.Version > last {
+			last = migration.Version
+		}
+	}
+
+	return last + 1
+}
+
+// Fix renames timestamp-named migrations, which have not been applied yet,
+// into sequential versions following the last sequential one.
+func (m *Migrate) Fix() ([]Rename, error) {
+	renames := make([]Rename, 0)
+
+	if m.fsys != nil {
+		return renames, ErrFixUnsupported
+	}
+
+	if err := m.lock(); err != nil {
+		return renames, err
+	}
+
+	available, err := m.findAvailableMigrations()
+	if err != nil {
+		return renames, m.unlock(err)
+	}
+
+	list, err := m.list()
+	if err != nil {
+		return renames, m.unlock(err)
+	}
+
+	// Any tracked migration (applied, failed or running) keeps its version.
+	// Up applies lower versions too, so only sequential versions must not be reused.
+	tracked := make(map[int64]bool, len(list))
+	var lastTracked int64
+	for _, info := range list {
+		tracked[info.Version] = true
+		if !IsTimestampVersion(info.Version) {
+			lastTracked = max(lastTracked, info.Version)
+		}
+	}
+
+	next := NextSequentialVersion(available)
+	replacements := make(map[int64]string)
+
+	for _, migration := range available {
+		if !IsTimestampVersion(migration.Version) || tracked[migration.Version] {
+			continue
+		}
+
+		// Tracked sequential migration without file would have the same version.
+		if next <= lastTracked {
+			return renames, m.unlock(fmt.Errorf(
+				"can't renumber %s: version %d is already applied, sequential version %d would be lower",
+				migration.Source, lastTracked, next,
+			))
+		}
+
+		version := fmt.Sprintf(SequentialVersionFormat, next)
+		to := version + strings.TrimPrefix(migration.Source, strconv.FormatInt(migration.Version, 10))
+
+		if _, err := os.Stat(filepath.Join(m.dir, to)); !os.IsNotExist(err) {
+			return renames, m.unlock(fmt.Errorf("can't rename %s: file %s already exists", migration.Source, to))
+		}
+
+		replacements[migration.Version] = version
+		renames = append(renames, Rename{From: migration.Source, To: to})
+		next++
+	}
+
+	if len(renames) == 0 {
+		return renames, m.unlock(ErrNothingToFix)
+	}
+
+	// Migrations must keep depending on renamed ones, so their annotations are rewritten before rename.
+	if err := m.replaceVersions(replacements); err != nil {
+		return renames, m.unlock(err)
+	}
+
+	for _, rename := range renames {
+		if err := os.Rename(filepath.Join(m.dir, rename.From), filepath.Join(m.dir, rename.To)); err != nil {
+			return renames, m.unlock(fmt.Errorf("can't rename %s: %w", rename.From, err))
+		}
+
+		m.printLog(fmt.Sprintf("Migration %s renamed to %s", rename.From, rename.To))
+	}
+
+	return renames, m.unlock(nil)
+}
+
+// Rewrite Depends and Baseline annotations, which reference replaced versions.
+func (m *Migrate) replaceVersions(replacements map[int64]string) error {
+	all, err := m.readMigrations()
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range all {
+		referenced := false
+		for _, version := range slices.Concat(migration.Depends, migration.Baseline) {
+			_, ok := replacements[version]
+			referenced = referenced || ok
+		}
+
+		if !referenced {
+			continue
+		}
+
+		path := filepath.Join(m.dir, migration.Source)
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		replaced, err := parser.ReplaceVersions(string(content), replacements)
+		if err != nil {
+			return fmt.Errorf("can't rewrite versions in %s: %w", migration.Source, err)
+		}
+
+		if err := os.WriteFile(path, []byte(replaced), info.Mode().Perm()); err != nil {
+			return fmt.Errorf("can't rewrite versions in %s: %w", migration.Source, err)
+		}
+
+		m.printLog(fmt.Sprintf("Versions of renamed migrations replaced in %s", migration.Source))
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package core
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database"
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Create migrator on top of clean memory database with the given migration files.
+func newMemoryMigrator(t *testing.T, files map[string]string) (*Migrate, *memory.Database) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	db := memory.Get(t.Name())
+	db.Reset()
+
+	migrator, err := NewMigrator(memory.DSN(t.Name()), "migrations", dir)
+	require.NoError(t, err)
+
+	return migrator, db
+}
+
+func migrationContent(up string, down string) string {
+	return "-- +gomigrator Up\n" + up + "\n-- +gomigrator Down\n" + down + "\n"
+}
+
+func appliedVersions(db *memory.Database) []int64 {
+	versions := make([]int64, 0)
+	for _, info := range db.Versions("migrations") {
+		if info.Status == database.StatusApplied {
+			versions = append(versions, info.Version)
+		}
+	}
+
+	return versions
+}
+
+func TestUpDownFlow(t *testing.T) {
+	migrator, db := newMemoryMigrator(t, map[string]string{
+		"1_users.sql":  migrationContent("CREATE TABLE users;", "DROP TABLE users;"),
+		"2_orders.sql": migrationContent("CREATE TABLE orders;", "DROP TABLE orders;"),
+	})
+
+	require.NoError(t, migrator.Up())
+	assert.Equal(t, []int64{1, 2}, appliedVersions(db))
+	require.ErrorIs(t, migrator.Up(), ErrAlreadyUpToDate)
+
+	require.NoError(t, migrator.Down())
+	assert.Equal(t, []int64{1}, appliedVersions(db))
+
+	require.NoError(t, migrator.Redo())
+	assert.Equal(t, []int64{1}, appliedVersions(db))
+
+	require.NoError(t, migrator.Down())
+	require.ErrorIs(t, migrator.Down(), ErrAlreadyUpToDate)
+
+	assert.Equal(t, []string{
+		"CREATE TABLE users;\n",
+		"CREATE TABLE orders;\n",
+		"DROP TABLE orders;\n",
+		"DROP TABLE users;\n",
+		"CREATE TABLE users;\n",
+		"DROP TABLE users;\n",
+	}, db.Executed())
+	assert.False(t, db.Locked())
+}
+
+func TestUpFailureAndRepair(t *testing.T) {
+	migrator, db := newMemoryMigrator(t, map[string]string{
+		"1_users.sql":  migrationContent("CREATE TABLE users;", "DROP TABLE users;"),
+		"2_orders.sql": migrationContent("CREATE TABLE orders;", "DROP TABLE orders;"),
+		"3_items.sql":  migrationContent("CREATE TABLE items;", "DROP TABLE items;"),
+	})
+	db.FailOn(2, errors.New("syntax error"))
+
+	require.ErrorContains(t, migrator.Up(), "syntax error")
+
+	versions := db.Versions("migrations")
+	require.Len(t, versions, 2)
+	assert.Equal(t, database.StatusFailed, versions[1].Status)
+	assert.Equal(t, "syntax error", versions[1].Error)
+
+	// Failed migration blocks the next runs until repair.
+	require.ErrorIs(t, migrator.Up(), ErrFailedMigration)
+
+	repaired, err := migrator.Repair()
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, repaired)
+
+	// Up resumes from the repaired migration.
+	db.ClearFailures()
+	executed := len(db.Executed())
+
+	require.NoError(t, migrator.Up())
+	assert.Equal(t, []int64{1, 2, 3}, appliedVersions(db))
+	assert.Equal(t, []string{"CREATE TABLE orders;\n", "CREATE TABLE items;\n"}, db.Executed()[executed:])
+}
+
+func TestUpLocked(t *testing.T) {
+	migrator, db := newMemoryMigrator(t, map[string]string{
+		"1_users.sql": migrationContent("CREATE TABLE users;", "DROP TABLE users;"),
+	})
+
+	other, err := NewMigrator(memory.DSN(t.Name()), "migrations", migrator.dir)
+	require.NoError(t, err)
+	require.NoError(t, other.lock())
+
+	require.ErrorIs(t, migrator.Up(), database.ErrLocked)
+	assert.Empty(t, db.Executed())
+
+	require.NoError(t, other.unlock(nil))
+	require.NoError(t, migrator.Up())
+}
+
+func TestUpOutOfOrderDependencies(t *testing.T) {
+	migrator, db := newMemoryMigrator(t, map[string]string{
+		"1_users.sql":  migrationContent("CREATE TABLE users;", "DROP TABLE users;"),
+		"3_orders.sql": migrationContent("CREATE TABLE orders;", "DROP TABLE orders;"),
+	})
+	require.NoError(t, migrator.Up())
+
+	// Migration of parallel branch with lower version is applied after merge.
+	content := "-- +gomigrator Depends: 1\n" + migrationContent("CREATE TABLE items;", "DROP TABLE items;")
+	require.NoError(t, os.WriteFile(filepath.Join(migrator.dir, "2_items.sql"), []byte(content), 0o600))
+
+	require.NoError(t, migrator.Up())
+	assert.Equal(t, []int64{1, 2, 3}, appliedVersions(db))
+	assert.Equal(t, "CREATE TABLE items;\n", db.Executed()[2])
+}
+
+func TestUpBaseline(t *testing.T) {
+	migrator, db := newMemoryMigrator(t, map[string]string{
+		"1_users.sql":  migrationContent("CREATE TABLE users;", "DROP TABLE users;"),
+		"2_orders.sql": migrationContent("CREATE TABLE orders;", "DROP TABLE orders;"),
+		"3_items.sql":  migrationContent("CREATE TABLE items;", "DROP TABLE items;"),
+	})
+	require.NoError(t, migrator.Up())
+
+	_, err := Squash(migrator.dir, "", 3, "")
+	require.NoError(t, err)
+
+	// Database with all squashed migrations doesn't run baseline.
+	require.ErrorIs(t, migrator.Up(), ErrAlreadyUpToDate)
+	assert.Len(t, db.Executed(), 3)
+
+	// Fresh database runs it.
+	db.Reset()
+	require.NoError(t, migrator.Up())
+	assert.Equal(t, []int64{3}, appliedVersions(db))
+	assert.Len(t, db.Executed(), 1)
+
+	// Partially applied squashed migrations can't be baselined.
+	db.Reset()
+	require.NoError(t, migrator.setVersion(1))
+	require.ErrorIs(t, migrator.Up(), ErrPartialBaseline)
+}
+
+func TestRepeatableFlow(t *testing.T) {
+	migrator, db := newMemoryMigrator(t, map[string]string{
+		"1_users.sql": migrationContent("CREATE TABLE users;", "DROP TABLE users;"),
+		"R_views.sql": "-- +gomigrator Up\nCREATE OR REPLACE VIEW v AS SELECT 1;\n",
+	})
+
+	require.NoError(t, migrator.Up())
+	require.ErrorIs(t, migrator.Up(), ErrAlreadyUpToDate)
+	assert.Len(t, db.Executed(), 2)
+
+	content := "-- +gomigrator Up\nCREATE OR REPLACE VIEW v AS SELECT 2;\n"
+	require.NoError(t, os.WriteFile(filepath.Join(migrator.dir, "R_views.sql"), []byte(content), 0o600))
+
+	require.NoError(t, migrator.Up())
+	assert.Equal(t, "CREATE OR REPLACE VIEW v AS SELECT 2;\n", db.Executed()[2])
+}
+
+// Driver of application, which wraps another driver.
+type countingDriver struct {
+	database.Driver
+	runs int
+}
+
+func (d *countingDriver) Run(migration io.Reader) error {
+	d.runs++
+
+	return d.Driver.Run(migration)
+}
+
+func TestNewMigratorWithDriver(t *testing.T) {
+	dir := t.TempDir()
+	content := migrationContent("CREATE TABLE users;", "DROP TABLE users;")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "1_users.sql"), []byte(content), 0o600))
+
+	db := memory.Get(t.Name())
+	db.Reset()
+
+	opened, err := database.Open(memory.DSN(t.Name()), "app_migrations")
+	require.NoError(t, err)
+
+	driver := &countingDriver{Driver: opened}
+	migrator, err := NewMigratorWithDriver(driver, "app_migrations", dir)
+	require.NoError(t, err)
+	defer migrator.Close()
+
+	require.NoError(t, migrator.Up())
+	assert.Equal(t, 1, driver.runs)
+	assert.Len(t, db.Versions("app_migrations"), 1)
+	assert.Equal(t, "app_migrations", migrator.tableName)
+}
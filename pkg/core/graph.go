@@ -0,0 +1,89 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrMissingDependency = errors.New("missing dependency")
+	ErrDependencyCycle   = errors.New("cyclic dependency between migrations")
+)
+
+// Sort migrations in topological order, so every migration follows its dependencies.
+// Independent migrations are ordered by version.
+func sortByDependencies(migrations Migrations) (Migrations, error) {
+	byVersion := make(map[int64]*Migration, len(migrations))
+	for _, migration := range migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	// Dependency on squashed migration is dependency on its baseline.
+	for _, migration := range migrations {
+		for _, version := range migration.Baseline {
+			if _, ok := byVersion[version]; !ok {
+				byVersion[version] = migration
+			}
+		}
+	}
+
+	// Count of not sorted dependencies and reverse edges.
+	pending := make(map[int64]int, len(migrations))
+	dependents := make(map[int64][]int64, len(migrations))
+
+	missing := make([]string, 0)
+	for _, migration := range migrations {
+		for _, dep := range migration.Depends {
+			target, ok := byVersion[dep]
+			if !ok {
+				missing = append(missing, fmt.Sprintf("%s depends on %d", migration.Source, dep))
+				continue
+			}
+
+			pending[migration.Version]++
+			dependents[target.Version] = append(dependents[target.Version], migration.Version)
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMissingDependency, strings.Join(missing, "; "))
+	}
+
+	ready := make([]int64, 0)
+	for _, migration := range migrations {
+		if pending[migration.Version] == 0 {
+			ready = append(ready, migration.Version)
+		}
+	}
+
+	sorted := make(Migrations, 0, len(migrations))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return ready[i] < ready[j] })
+
+		version := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, byVersion[version])
+
+		for _, dependent := range dependents[version] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if len(sorted) < len(migrations) {
+		cycle := make([]string, 0)
+		for _, migration := range migrations {
+			if pending[migration.Version] > 0 {
+				cycle = append(cycle, migration.Source)
+			}
+		}
+
+		return nil, fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(cycle, ", "))
+	}
+
+	return sorted, nil
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortByDependencies(t *testing.T) {
+	migrations := Migrations{
+		{Version: 1, Source: "1_users.sql"},
+		{Version: 2, Source: "2_orders.sql", Depends: []int64{4}},
+		{Version: 3, Source: "3_products.sql"},
+		{Version: 4, Source: "4_customers.sql", Depends: []int64{1}},
+	}
+
+	sorted, err := sortByDependencies(migrations)
+	require.NoError(t, err)
+
+	versions := make([]int64, 0, len(sorted))
+	for _, migration := range sorted {
+		versions = append(versions, migration.Version)
+	}
+	assert.Equal(t, []int64{1, 3, 4, 2}, versions)
+}
+
+func TestSortByDependenciesErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		migrations Migrations
+		err        error
+		message    string
+	}{
+		{
+			name: "missing dependency",
+			migrations: Migrations{
+				{Version: 1, Source: "1_users.sql", Depends: []int64{5}},
+			},
+			err:     ErrMissingDependency,
+			message: "1_users.sql depends on 5",
+		},
+		{
+			name: "cycle",
+			migrations: Migrations{
+				{Version: 1, Source: "1_users.sql"},
+				{Version: 2, Source: "2_orders.sql", Depends: []int64{3}},
+				{Version: 3, Source: "3_products.sql", Depends: []int64{2}},
+			},
+			err:     ErrDependencyCycle,
+			message: "2_orders.sql, 3_products.sql",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := sortByDependencies(tt.migrations)
+			require.ErrorIs(t, err, tt.err)
+			assert.Contains(t, err.Error(), tt.message)
+		})
+	}
+}
+
+func TestParseDepends(t *testing.T) {
+	dir := writeMigrations(t, "1_users.sql", "2_products.sql")
+	content := "-- +gomigrator Depends: 1, 2\n-- +gomigrator Up\nSELECT 1;\n-- +gomigrator Down\nSELECT 1;\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "3_orders.sql"), []byte(content), 0o600))
+
+	migrations, err := Discover(dir, "")
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+	assert.Equal(t, []int64{1, 2}, migrations[2].Depends)
+}
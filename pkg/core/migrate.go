@@ -1,138 +1,228 @@
 package core
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"io/fs"
-	"net/http"
-	"path"
-	"slices"
-	"sort"
-	"strconv"
+	"regexp"
 	"strings"
+	"time"
 
-	"github.com/EvgenyRomanov/sql-migrator/internal/database"
+	_ "github.com/EvgenyRomanov/sql-migrator/internal/database/mysql"    // Add MySQL support.
+	_ "github.com/EvgenyRomanov/sql-migrator/internal/database/pgx"      // Add pgx support.
 	_ "github.com/EvgenyRomanov/sql-migrator/internal/database/postgres" // Add pg support.
-	"github.com/EvgenyRomanov/sql-migrator/internal/logger"
-	"github.com/EvgenyRomanov/sql-migrator/internal/parser"
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database"
 )
 
 var (
 	ErrNoCurrentVersion      = errors.New("no current version found. Please check your DB state")
 	ErrNoAvailableMigrations = errors.New("no available migrations found")
 	ErrAlreadyUpToDate       = errors.New("already up to date")
+	ErrFailedMigration       = errors.New("previous migration failed, repair is required")
+	ErrNothingToRepair       = errors.New("nothing to repair")
+	ErrOutOfOrder            = errors.New("migrations are older than the latest applied one")
 )
 
 const DefaultTableName = "migrations"
 
+// StatusOutdated is status of repeatable migration which has been changed since last run.
+const StatusOutdated = "outdated"
+
+// Interval between attempts to acquire lock held by another process.
+const lockRetryInterval = 100 * time.Millisecond
+
+// RepeatablePrefix marks repeatable migration files (in addition to "Repeatable" annotation).
+const RepeatablePrefix = "R_"
+
 type Migrate struct {
-	Log       *logger.Logger
-	driver    database.Driver
-	tableName string
-	dir       string
+	Log Logger
+
+	// Path of file to write schema snapshot to after successful up, down and redo (disabled if empty).
+	SchemaFile string
+
+	driver      database.Driver
+	dsn         string
+	tableName   string
+	seedsTable  string
+	dir         string
+	fsys        fs.FS
+	filePattern *regexp.Regexp
+	lockTimeout time.Duration
+	outOfOrder  bool
+	observers   []Observer
+
+	// Callback files are not run (e.g. by scratch migrator of Drift).
+	skipCallbacks bool
+
+	// Values of placeholders in SQL, undefined placeholders are errors in strict mode.
+	variables       map[string]string
+	strictVariables bool
 }
 
 // Migrations slice.
 type Migrations []*Migration
 
+// NewMigrator returns migrator for database with the given DSN.
 func NewMigrator(dsn string, tableName string, dir string) (*Migrate, error) {
-	if tableName == "" {
-		tableName = DefaultTableName
+	return Open(WithDSN(dsn), WithTableName(tableName), WithDir(dir))
+}
+
+// NewMigratorFromDB returns migrator, which uses database connection of application.
+// Dialect is name of driver (e.g. "postgres", "pgx", "mysql", "sqlite"), db isn't closed by Close.
+func NewMigratorFromDB(db *sql.DB, dialect string, tableName string, dir string) (*Migrate, error) {
+	return Open(WithDB(db, dialect), WithTableName(tableName), WithDir(dir))
+}
+
+// NewMigratorWithDriver returns migrator, which uses already opened driver
+// (e.g. driver of another database or driver on top of existing connection pool).
+// Driver should be opened with the same table name, it is closed by Close.
+// Drift with scratch schema requires DSN, so it is not supported by such migrator.
+func NewMigratorWithDriver(driver database.Driver, tableName string, dir string) (*Migrate, error) {
+	return Open(WithDriver(driver, tableName), WithDir(dir))
+}
+
+func (m *Migrate) Up() error {
+	return m.runCommand(CommandUp, m.up)
+}
+
+func (m *Migrate) up(run *RunEvent) error {
+	// Refuse to go further until failed migrations are repaired.
+	if err := m.checkFailed(); err != nil {
+		return err
 	}
 
-	// Get driver.
-	driver, err := database.Open(dsn, tableName)
-	if err != nil {
-		return nil, fmt.Errorf("can't get driver: %w", err)
+	// Databases, which applied squashed migrations, already have their baseline.
+	if err := m.applyBaselines(); err != nil {
+		return err
+	}
+
+	// Nothing to run for versioned migrations doesn't stop repeatable ones.
+	migrations, forRunErr := m.migrationsForRun(true, 0)
+	if forRunErr != nil && !errors.Is(forRunErr, ErrAlreadyUpToDate) &&
+		!errors.Is(forRunErr, ErrNoAvailableMigrations) {
+		return forRunErr
 	}
 
-	migrate := &Migrate{
-		driver:    driver,
-		tableName: tableName,
-		dir:       dir,
+	if !m.outOfOrder {
+		if err := m.checkOrder(migrations); err != nil {
+			return err
+		}
+	}
+
+	// Undefined variables are reported before anything is applied.
+	if m.strictVariables {
+		if err := m.checkVariables(migrations); err != nil {
+			return err
+		}
+	}
+
+	if err := m.runCallback(CallbackBeforeMigrate); err != nil {
+		return err
+	}
+
+	for _, migration := range migrations {
+		if err := m.runUp(run, migration); err != nil {
+			return err
+		}
 	}
 
-	// Create table if it does not exist.
-	err = migrate.prepareDatabase()
+	appliedRepeatable, err := m.runRepeatable(run)
 	if err != nil {
-		return nil, fmt.Errorf("can't initialize table: %w", err)
+		return err
+	}
+
+	if err := m.runCallback(CallbackAfterMigrate); err != nil {
+		return err
+	}
+
+	if len(migrations) == 0 && appliedRepeatable == 0 {
+		return forRunErr
 	}
 
-	return migrate, nil
+	return m.dumpSchema()
 }
 
-func (m *Migrate) Up() error {
+// Repair removes failed and running (interrupted) migrations from DB,
+// so that Up can be run again.
+func (m *Migrate) Repair() ([]int64, error) {
+	repaired := make([]int64, 0)
+
 	if err := m.lock(); err != nil {
-		return err
+		return repaired, err
 	}
 
-	migrations, err := m.migrationsForRun(true, 0)
+	list, err := m.list()
 	if err != nil {
-		return m.unlock(err)
+		return repaired, m.unlock(err)
 	}
 
-	for _, migration := range migrations {
-		if err := m.driver.Run(strings.NewReader(migration.UpSQL)); err != nil {
-			return m.unlock(fmt.Errorf("can't execute migration with version %d: %w", migration.Version, err))
+	for _, info := range list {
+		if info.Status == database.StatusApplied {
+			continue
 		}
 
-		// Set version if success.
-		m.setVersion(migration.Version)
-		m.printLog(fmt.Sprintf("Migration %d successfully applied!", migration.Version))
+		if err := m.deleteVersion(info.Version); err != nil {
+			return repaired, m.unlock(err)
+		}
+
+		repaired = append(repaired, info.Version)
+		m.printLog(fmt.Sprintf("Migration %d in status %q successfully repaired!", info.Version, info.Status))
 	}
 
-	return m.unlock(nil)
+	if len(repaired) == 0 {
+		return repaired, m.unlock(ErrNothingToRepair)
+	}
+
+	return repaired, m.unlock(nil)
 }
 
 func (m *Migrate) Down() error {
-	if err := m.lock(); err != nil {
-		return err
-	}
+	return m.runCommand(CommandDown, m.down)
+}
 
+func (m *Migrate) down(run *RunEvent) error {
 	migrations, err := m.migrationsForRun(false, 1)
 	if err != nil {
-		return m.unlock(err)
+		return err
 	}
 
 	for _, migration := range migrations {
-		if err := m.driver.Run(strings.NewReader(migration.DownSQL)); err != nil {
-			return m.unlock(fmt.Errorf("can't rollback migration with version %d: %w", migration.Version, err))
+		if err := m.runDown(run, migration); err != nil {
+			return err
 		}
-
-		// Delete version if success.
-		m.deleteVersion(migration.Version)
-		m.printLog(fmt.Sprintf("Migration %d successfully rollback!", migration.Version))
 	}
 
-	return m.unlock(nil)
+	return m.dumpSchema()
 }
 
 func (m *Migrate) Redo() error {
-	if err := m.lock(); err != nil {
-		return err
-	}
+	return m.runCommand(CommandRedo, m.redo)
+}
 
-	currentMigration, err := m.currentMigration()
-	if errors.Is(err, ErrNoCurrentVersion) {
-		m.printLog(err.Error())
-		return m.unlock(nil)
+func (m *Migrate) redo(run *RunEvent) error {
+	// The latest migration is the first one to rollback.
+	migrations, err := m.migrationsForRun(false, 1)
+	if errors.Is(err, ErrAlreadyUpToDate) || errors.Is(err, ErrNoAvailableMigrations) {
+		m.printLog(ErrNoCurrentVersion.Error())
+		return nil
 	}
+	if err != nil {
+		return err
+	}
+	currentMigration := migrations[0]
 
-	// Rollback it first.
-	if err := m.driver.Run(strings.NewReader(currentMigration.DownSQL)); err != nil {
-		return m.unlock(fmt.Errorf("can't rollback migration with version %d: %w", currentMigration.Version, err))
+	// Rollback it first...
+	if err := m.runDown(run, currentMigration); err != nil {
+		return err
 	}
-	m.deleteVersion(currentMigration.Version)
-	m.printLog(fmt.Sprintf("Migration %d successfully rollback!", currentMigration.Version))
 
 	// ...and then run to up
-	if err := m.driver.Run(strings.NewReader(currentMigration.UpSQL)); err != nil {
-		return m.unlock(fmt.Errorf("can't execute migration with version %d: %w", currentMigration.Version, err))
+	if err := m.runUp(run, currentMigration); err != nil {
+		return err
 	}
-	m.setVersion(currentMigration.Version)
-	m.printLog(fmt.Sprintf("Migration %d successfully applied!", currentMigration.Version))
 
-	return m.unlock(nil)
+	return m.dumpSchema()
 }
 
 func (m *Migrate) DBVersion() (int64, error) {
@@ -179,12 +269,21 @@ func (m *Migrate) Status() (Migrations, error) {
 	for _, appliedMigration := range list {
 		migration, err := m.getMigrationByVersion(availableMigrations, appliedMigration.Version)
 		if err == nil {
-			// Add applied_at time.
+			// Add applied_at time and status.
 			migration.AppliedAt = appliedMigration.AppliedAt
+			migration.Status = appliedMigration.Status
+			migration.Error = appliedMigration.Error
 			migrations = append(migrations, migration)
 		}
 	}
 
+	// Add applied repeatable migrations.
+	repeatable, err := m.repeatableStatus()
+	if err != nil {
+		return migrations, m.unlock(err)
+	}
+	migrations = append(migrations, repeatable...)
+
 	m.unlock(nil)
 
 	return migrations, nil
@@ -205,52 +304,40 @@ func (m *Migrate) migrationsForRun(up bool, limit int) (Migrations, error) {
 		return make(Migrations, 0), ErrNoAvailableMigrations
 	}
 
+	// Order migrations by their dependencies.
+	availableMigrations, err = sortByDependencies(availableMigrations)
+	if err != nil {
+		return make(Migrations, 0), err
+	}
+
 	// Get list of applied migrations.
 	listAppliedMigrations, err := m.list()
 	if err != nil {
 		return make(Migrations, 0), err
 	}
 
-	appliedVersions := make([]int64, 0)
+	appliedVersions := make(map[int64]bool, len(listAppliedMigrations))
 	for _, ap := range listAppliedMigrations {
-		appliedVersions = append(appliedVersions, ap.Version)
-	}
-
-	// If we go down and don't have any applied migrations - do nothing.
-	if len(appliedVersions) == 0 && !up {
-		return make(Migrations, 0), ErrAlreadyUpToDate
-	}
-
-	// If we go up and don't have any applied migrations - run all then.
-	if len(appliedVersions) == 0 && up {
-		return availableMigrations, nil
+		if ap.Status == database.StatusApplied {
+			appliedVersions[ap.Version] = true
+		}
 	}
 
 	var migrationsForRun Migrations
 
-	// Calc the difference between them.
-	if !up {
-		// Sort desc.
-		sort.Slice(availableMigrations, func(i, j int) bool {
-			return availableMigrations[i].Version > availableMigrations[j].Version
-		})
-
-		// Filter them.
+	if up {
+		// Every not applied migration follows its dependencies,
+		// so migrations of independent branches are applied whatever their versions are.
 		for _, migration := range availableMigrations {
-			if migration.Version <= appliedVersions[len(appliedVersions)-1] {
+			if !appliedVersions[migration.Version] {
 				migrationsForRun = append(migrationsForRun, migration)
 			}
 		}
 	} else {
-		// Filter them.
-		for _, migration := range availableMigrations {
-			// If it already applied - skip.
-			if slices.Contains(appliedVersions, migration.Version) {
-				continue
-			}
-
-			if migration.Version > appliedVersions[0] {
-				migrationsForRun = append(migrationsForRun, migration)
+		// Rollback in reverse order, so dependent migrations are rolled back first.
+		for i := len(availableMigrations) - 1; i >= 0; i-- {
+			if appliedVersions[availableMigrations[i].Version] {
+				migrationsForRun = append(migrationsForRun, availableMigrations[i])
 			}
 		}
 	}
@@ -260,114 +347,254 @@ func (m *Migrate) migrationsForRun(up bool, limit int) (Migrations, error) {
 	}
 
 	// Slice target slice.
-	if limit > 0 {
+	if limit > 0 && limit < len(migrationsForRun) {
 		migrationsForRun = migrationsForRun[0:limit]
 	}
 
 	return migrationsForRun, nil
 }
 
-func (m *Migrate) currentMigration() (*Migration, error) {
-	// Get available migrations.
-	availableMigrations, err := m.findAvailableMigrations()
+// Run command under lock notifying observers.
+func (m *Migrate) runCommand(command string, fn func(run *RunEvent) error) error {
+	if err := m.lock(); err != nil {
+		return err
+	}
+
+	run := m.beforeAll(command)
+	err := fn(run)
+	m.afterAll(run, err)
+
+	return m.unlock(err)
+}
+
+// Run up statements of migration tracking its status in DB.
+// Failure of callback after recorded migration is not a failure of migration itself.
+func (m *Migrate) runUp(run *RunEvent, migration *Migration) error {
+	err := m.observeMigration(run, DirectionUp, migration, func() error {
+		upSQL, err := m.substitute(migration.Source, migration.UpSQL)
+		if err != nil {
+			return err
+		}
+
+		if err := m.runCallback(CallbackBeforeEachMigrate); err != nil {
+			return err
+		}
+
+		if err := m.setStatus(migration.Version, database.StatusRunning, ""); err != nil {
+			return err
+		}
+
+		if err := m.driver.Run(strings.NewReader(upSQL)); err != nil {
+			runErr := fmt.Errorf("can't execute migration with version %d: %w", migration.Version, err)
+
+			if statusErr := m.setStatus(migration.Version, database.StatusFailed, err.Error()); statusErr != nil {
+				return fmt.Errorf("%w. Additional err: %w", runErr, statusErr)
+			}
+
+			return runErr
+		}
+
+		// Set version if success.
+		return m.setVersion(migration.Version)
+	})
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// Get current migration version from DB.
-	currentVersion, err := m.current()
+	return m.runCallback(CallbackAfterEachMigrate)
+}
+
+// Run down statements of migration and delete its version.
+func (m *Migrate) runDown(run *RunEvent, migration *Migration) error {
+	return m.observeMigration(run, DirectionDown, migration, func() error {
+		downSQL, err := m.substitute(migration.Source, migration.DownSQL)
+		if err != nil {
+			return err
+		}
+
+		if err := m.driver.Run(strings.NewReader(downSQL)); err != nil {
+			return fmt.Errorf("can't rollback migration with version %d: %w", migration.Version, err)
+		}
+
+		// Delete version if success.
+		return m.deleteMigration(migration)
+	})
+}
+
+// Apply repeatable migrations which checksum differs from the last applied one.
+// Returns count of applied migrations.
+func (m *Migrate) runRepeatable(run *RunEvent) (int, error) {
+	migrations, err := m.findRepeatableMigrations()
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	// Get available migration by version.
-	migration, err := m.getMigrationByVersion(availableMigrations, currentVersion)
+	checksums, err := m.repeatableChecksums()
 	if err != nil {
-		return nil, ErrNoCurrentVersion
+		return 0, err
 	}
 
-	return migration, nil
-}
+	applied := 0
+	for _, migration := range migrations {
+		if checksums[migration.Source] == migration.Checksum {
+			continue
+		}
 
-func (m *Migrate) getMigrationByVersion(migrations Migrations, version int64) (*Migration, error) {
-	for _, migrate := range migrations {
-		if migrate.Version == version {
-			return migrate, nil
+		err := m.observeMigration(run, DirectionUp, migration, func() error {
+			upSQL, err := m.substitute(migration.Source, migration.UpSQL)
+			if err != nil {
+				return err
+			}
+
+			if err := m.runCallback(CallbackBeforeEachMigrate); err != nil {
+				return err
+			}
+
+			if err := m.driver.Run(strings.NewReader(upSQL)); err != nil {
+				return fmt.Errorf("can't execute repeatable migration %s: %w", migration.Source, err)
+			}
+
+			if err := m.driver.SetRepeatable(migration.Source, migration.Checksum); err != nil {
+				return fmt.Errorf("can't set repeatable migration checksum: %w", err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return applied, err
+		}
+
+		applied++
+
+		if err := m.runCallback(CallbackAfterEachMigrate); err != nil {
+			return applied, err
 		}
 	}
 
-	return nil, fmt.Errorf("no migration find by version %d", version)
+	return applied, nil
 }
 
-func (m *Migrate) findAvailableMigrations() (Migrations, error) {
-	migrations := make([]*Migration, 0)
+// Get status of applied repeatable migrations.
+// Migration, which file has been changed since last run, has status "outdated".
+func (m *Migrate) repeatableStatus() (Migrations, error) {
+	migrations := make(Migrations, 0)
 
-	file, err := http.Dir(m.dir).Open(".")
+	available, err := m.findRepeatableMigrations()
 	if err != nil {
-		return nil, err
+		return migrations, err
 	}
 
-	files, err := file.Readdir(0)
+	list, err := m.driver.ListRepeatable()
 	if err != nil {
-		return nil, err
+		return migrations, fmt.Errorf("can't get list of applied repeatable migraions: %w", err)
 	}
 
-	for _, info := range files {
-		name := info.Name()
-		if strings.HasSuffix(name, ".sql") {
-			migration, err := m.parseSQLMigration(info)
-			if err != nil {
-				return nil, err
+	for _, info := range list {
+		for _, migration := range available {
+			if migration.Source != info.Name {
+				continue
+			}
+
+			migration.AppliedAt = info.AppliedAt
+			migration.Status = database.StatusApplied
+			if migration.Checksum != info.Checksum {
+				migration.Status = StatusOutdated
 			}
 
 			migrations = append(migrations, migration)
 		}
 	}
 
-	// Insure then they are sorted by version correctly.
-	sort.Slice(migrations, func(i, j int) bool {
-		return migrations[i].Version < migrations[j].Version
-	})
-
 	return migrations, nil
 }
 
-// Parse SQL migration file.
-func (m *Migrate) parseSQLMigration(info fs.FileInfo) (*Migration, error) {
-	file, err := http.Dir(m.dir).Open(path.Join("./", info.Name()))
+func (m *Migrate) repeatableChecksums() (map[string]string, error) {
+	list, err := m.driver.ListRepeatable()
+	if err != nil {
+		return nil, fmt.Errorf("can't get list of applied repeatable migraions: %w", err)
+	}
+
+	checksums := make(map[string]string, len(list))
+	for _, info := range list {
+		checksums[info.Name] = info.Checksum
+	}
+
+	return checksums, nil
+}
+
+// Return ErrFailedMigration if there are failed or interrupted migrations in DB.
+func (m *Migrate) checkFailed() error {
+	list, err := m.list()
 	if err != nil {
-		return nil, fmt.Errorf("error while opening %s: %w", info.Name(), err)
+		return err
+	}
+
+	for _, info := range list {
+		if info.Status == database.StatusApplied {
+			continue
+		}
+
+		if info.Error != "" {
+			return fmt.Errorf("%w: migration %d is %s: %s", ErrFailedMigration, info.Version, info.Status, info.Error)
+		}
+
+		return fmt.Errorf("%w: migration %d is %s", ErrFailedMigration, info.Version, info.Status)
 	}
-	defer func() { _ = file.Close() }()
 
+	return nil
+}
+
+// Refuse to apply migrations with versions lower than the latest applied one.
+func (m *Migrate) checkOrder(migrations Migrations) error {
+	current, err := m.current()
 	if err != nil {
-		return nil, fmt.Errorf("error while opening %s: %w", info.Name(), err)
+		return err
 	}
 
-	version := m.getVersionFromFileName(info.Name())
+	older := make([]string, 0)
+	for _, migration := range migrations {
+		if migration.Version < current {
+			older = append(older, migration.Source)
+		}
+	}
 
-	migration := &Migration{
-		Version: version,
-		Source:  info.Name(),
+	if len(older) > 0 {
+		return fmt.Errorf("%w (%d): %s", ErrOutOfOrder, current, strings.Join(older, ", "))
 	}
 
-	parsed, err := parser.ParseMigration(file)
+	return nil
+}
+
+func (m *Migrate) currentMigration() (*Migration, error) {
+	// Get available migrations.
+	availableMigrations, err := m.findAvailableMigrations()
 	if err != nil {
-		return nil, fmt.Errorf("error while parsing file %s: %w", info.Name(), err)
+		return nil, err
 	}
 
-	// Set statements.
-	migration.UpSQL = parsed.UpStatements
-	migration.DownSQL = parsed.DownStatements
+	// Get current migration version from DB.
+	currentVersion, err := m.current()
+	if err != nil {
+		return nil, err
+	}
+
+	// Get available migration by version.
+	migration, err := m.getMigrationByVersion(availableMigrations, currentVersion)
+	if err != nil {
+		return nil, ErrNoCurrentVersion
+	}
 
 	return migration, nil
 }
 
-func (m *Migrate) getVersionFromFileName(filename string) int64 {
-	version := strings.Split(filename, "_")[0]
-	i, _ := strconv.ParseInt(version, 10, 64)
+func (m *Migrate) getMigrationByVersion(migrations Migrations, version int64) (*Migration, error) {
+	for _, migrate := range migrations {
+		if migrate.Version == version {
+			return migrate, nil
+		}
+	}
 
-	return i
+	return nil, fmt.Errorf("no migration find by version %d", version)
 }
 
 // Create migrations table if it doesn't exist.
@@ -384,6 +611,15 @@ func (m *Migrate) setVersion(version int64) error {
 	return nil
 }
 
+func (m *Migrate) setStatus(version int64, status string, message string) error {
+	err := m.driver.SetStatus(version, status, message)
+	if err != nil {
+		return fmt.Errorf("can't set migraion status: %w", err)
+	}
+
+	return nil
+}
+
 func (m *Migrate) deleteVersion(version int64) error {
 	err := m.driver.DeleteVersion(version)
 	if err != nil {
@@ -412,9 +648,23 @@ func (m *Migrate) current() (int64, error) {
 	return curVersion, nil
 }
 
-// Lock the driver.
+// Lock the driver, waiting for lock held by another process up to lock timeout.
 func (m *Migrate) lock() error {
-	return m.driver.Lock()
+	started := time.Now()
+	deadline := started.Add(m.lockTimeout)
+
+	for {
+		err := m.driver.Lock()
+		if !errors.Is(err, database.ErrLocked) || !time.Now().Before(deadline) {
+			return err
+		}
+
+		for _, observer := range m.allObservers() {
+			observer.OnLockWait(time.Since(started))
+		}
+
+		time.Sleep(min(lockRetryInterval, time.Until(deadline)))
+	}
 }
 
 // Release lock and return err if exists.
@@ -1,6 +1,8 @@
 package core
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	_ "github.com/EvgenyRomanov/sql-migrator/internal/database/stub"
@@ -31,7 +33,38 @@ func TestFindAvailableMigrations(t *testing.T) {
 }
 
 func TestGetVersionFromFileName(t *testing.T) {
-	version := testMigrator.getVersionFromFileName("1234567_qwerty_test_migration.sql")
+	version, err := testMigrator.getVersionFromFileName("1234567_qwerty_test_migration.sql")
+	assert.NoError(t, err)
 	assert.NotEmpty(t, version)
 	assert.Equal(t, version, int64(1234567))
+
+	_, err = testMigrator.getVersionFromFileName("qwerty_test_migration.sql")
+	assert.Error(t, err)
+}
+
+func TestFindRepeatableMigrations(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"20250302201917_create_table.sql": "-- +gomigrator Up\nCREATE TABLE test (id int);\n-- +gomigrator Down\nDROP TABLE test;\n",
+		"R_test_view.sql":                 "-- +gomigrator Up\nCREATE OR REPLACE VIEW test_view AS SELECT * FROM test;\n",
+		"20250302211917_grants.sql":       "-- +gomigrator Repeatable\n-- +gomigrator Up\nGRANT SELECT ON test TO app;\n",
+	}
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	migrator := &Migrate{dir: dir}
+
+	versioned, err := migrator.findAvailableMigrations()
+	assert.NoError(t, err)
+	assert.Len(t, versioned, 1)
+
+	repeatable, err := migrator.findRepeatableMigrations()
+	assert.NoError(t, err)
+	assert.Len(t, repeatable, 2)
+
+	assert.Equal(t, "20250302211917_grants.sql", repeatable[0].Source)
+	assert.Equal(t, "R_test_view.sql", repeatable[1].Source)
+	assert.Equal(t, int64(0), repeatable[1].Version)
+	assert.NotEmpty(t, repeatable[1].Checksum)
 }
@@ -9,14 +9,35 @@ type Migration struct {
 	// Path to file.
 	Source string
 
-	// The time of migration application.
+	// The time of migration application (last status update).
 	AppliedAt time.Time
 
+	// Status of migration in DB (applied, running, failed).
+	Status string
+
+	// Error of last failed run.
+	Error string
+
 	// Statements to run up (used by SQL-migrations).
 	UpSQL string
 
 	// Statements to run down (used by SQL-migrations).
 	DownSQL string
+
+	// Repeatable migrations are re-applied after versioned ones whenever their checksum changes.
+	Repeatable bool
+
+	// Checksum of up statements.
+	Checksum string
+
+	// Environments where migration is applied, empty for all (used by seeds).
+	Environments []string
+
+	// Versions of migrations, which should be applied before this one.
+	Depends []int64
+
+	// Versions of migrations squashed into this baseline one.
+	Baseline []int64
 }
 
 func New() *Migration {
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// Commands, which run migrations.
+const (
+	CommandUp   = "up"
+	CommandDown = "down"
+	CommandRedo = "redo"
+)
+
+// Directions of migration run.
+const (
+	DirectionUp   = "up"
+	DirectionDown = "down"
+)
+
+// RunEvent describes run of command (up, down or redo).
+type RunEvent struct {
+	Command string
+
+	// Migrations successfully applied or rolled back by command (set for AfterAll).
+	Migrations Migrations
+
+	// Duration and result of command (set for AfterAll).
+	Duration time.Duration
+	Err      error
+
+	started time.Time
+}
+
+// MigrationEvent describes run of single migration.
+type MigrationEvent struct {
+	Command   string
+	Direction string
+	Migration *Migration
+
+	// Duration and result of migration (set for AfterMigration).
+	Duration time.Duration
+	Err      error
+}
+
+// Observer receives events of migrator, e.g. to emit metrics or send notifications.
+// Embed NopObserver to implement only some of methods.
+type Observer interface {
+	// BeforeAll is called after lock is acquired, before migrations of command are selected.
+	BeforeAll(event RunEvent)
+
+	// BeforeMigration is called before migration is applied or rolled back.
+	BeforeMigration(event MigrationEvent)
+
+	// AfterMigration is called after migration is applied or rolled back (successfully or not).
+	AfterMigration(event MigrationEvent)
+
+	// AfterAll is called before lock is released with result of command.
+	AfterAll(event RunEvent)
+
+	// OnLockWait is called before the next attempt to acquire lock held by another process
+	// (only if lock timeout is set).
+	OnLockWait(waited time.Duration)
+}
+
+// NopObserver ignores all events.
+type NopObserver struct{}
+
+func (NopObserver) BeforeAll(RunEvent)             {}
+func (NopObserver) BeforeMigration(MigrationEvent) {}
+func (NopObserver) AfterMigration(MigrationEvent)  {}
+func (NopObserver) AfterAll(RunEvent)              {}
+func (NopObserver) OnLockWait(time.Duration)       {}
+
+// Observer, which writes results of migrations to logger.
+type logObserver struct {
+	NopObserver
+	log Logger
+}
+
+func (o logObserver) AfterMigration(event MigrationEvent) {
+	if event.Err != nil {
+		return
+	}
+
+	switch {
+	case event.Direction == DirectionDown:
+		o.info(fmt.Sprintf("Migration %d successfully rollback!", event.Migration.Version))
+	case event.Migration.Repeatable:
+		o.info(fmt.Sprintf("Repeatable migration %s successfully applied!", event.Migration.Source))
+	default:
+		o.info(fmt.Sprintf("Migration %d successfully applied!", event.Migration.Version))
+	}
+}
+
+func (o logObserver) info(msg string) {
+	o.log.Info(msg) //nolint:govet
+}
+
+// Observers of migrator including logger.
+func (m *Migrate) allObservers() []Observer {
+	if m.Log == nil {
+		return m.observers
+	}
+
+	return append([]Observer{logObserver{log: m.Log}}, m.observers...)
+}
+
+func (m *Migrate) beforeAll(command string) *RunEvent {
+	run := &RunEvent{Command: command, started: time.Now()}
+
+	for _, observer := range m.allObservers() {
+		observer.BeforeAll(*run)
+	}
+
+	return run
+}
+
+func (m *Migrate) afterAll(run *RunEvent, err error) {
+	run.Duration = time.Since(run.started)
+	run.Err = err
+
+	for _, observer := range m.allObservers() {
+		observer.AfterAll(*run)
+	}
+}
+
+// Run migration in direction notifying observers, successful migration is added to run.
+func (m *Migrate) observeMigration(run *RunEvent, direction string, migration *Migration, fn func() error) error {
+	event := MigrationEvent{Command: run.Command, Direction: direction, Migration: migration}
+
+	for _, observer := range m.allObservers() {
+		observer.BeforeMigration(event)
+	}
+
+	started := time.Now()
+	err := fn()
+
+	event.Duration = time.Since(started)
+	event.Err = err
+
+	for _, observer := range m.allObservers() {
+		observer.AfterMigration(event)
+	}
+
+	if err == nil {
+		run.Migrations = append(run.Migrations, migration)
+	}
+
+	return err
+}
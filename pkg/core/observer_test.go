@@ -0,0 +1,112 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database"
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingObserver struct {
+	events    []string
+	runs      []RunEvent
+	lockWaits int
+}
+
+func (o *recordingObserver) BeforeAll(event RunEvent) {
+	o.events = append(o.events, "before all "+event.Command)
+}
+
+func (o *recordingObserver) BeforeMigration(event MigrationEvent) {
+	o.events = append(o.events, fmt.Sprintf("before %s %s", event.Direction, event.Migration.Source))
+}
+
+func (o *recordingObserver) AfterMigration(event MigrationEvent) {
+	o.events = append(o.events, fmt.Sprintf("after %s %s: %v", event.Direction, event.Migration.Source, event.Err))
+}
+
+func (o *recordingObserver) AfterAll(event RunEvent) {
+	o.events = append(o.events, fmt.Sprintf("after all %s: %v", event.Command, event.Err))
+	o.runs = append(o.runs, event)
+}
+
+func (o *recordingObserver) OnLockWait(time.Duration) {
+	o.lockWaits++
+}
+
+func TestObserver(t *testing.T) {
+	db := memory.Get(t.Name())
+	db.Reset()
+	db.FailOn(3, errors.New("syntax error"))
+
+	fsys := fstest.MapFS{
+		"1_users.sql":  {Data: []byte(migrationContent("CREATE TABLE users;", "DROP TABLE users;"))},
+		"2_orders.sql": {Data: []byte(migrationContent("CREATE TABLE orders;", "DROP TABLE orders;"))},
+		"R_views.sql":  {Data: []byte("-- +gomigrator Up\nCREATE VIEW v AS SELECT 1;\n")},
+	}
+	observer := &recordingObserver{}
+
+	migrator, err := Open(WithDSN(memory.DSN(t.Name())), WithFS(fsys), WithHooks(observer))
+	require.NoError(t, err)
+
+	require.NoError(t, migrator.Up())
+	require.NoError(t, migrator.Redo())
+
+	fsys["3_items.sql"] = &fstest.MapFile{Data: []byte(migrationContent("CREATE TABLE items;", "DROP TABLE items;"))}
+	require.Error(t, migrator.Up())
+
+	assert.Equal(t, []string{
+		"before all up",
+		"before up 1_users.sql",
+		"after up 1_users.sql: <nil>",
+		"before up 2_orders.sql",
+		"after up 2_orders.sql: <nil>",
+		"before up R_views.sql",
+		"after up R_views.sql: <nil>",
+		"after all up: <nil>",
+		"before all redo",
+		"before down 2_orders.sql",
+		"after down 2_orders.sql: <nil>",
+		"before up 2_orders.sql",
+		"after up 2_orders.sql: <nil>",
+		"after all redo: <nil>",
+		"before all up",
+		"before up 3_items.sql",
+		"after up 3_items.sql: can't execute migration with version 3: syntax error",
+		"after all up: can't execute migration with version 3: syntax error",
+	}, observer.events)
+
+	require.Len(t, observer.runs, 3)
+	assert.Len(t, observer.runs[0].Migrations, 3)
+	assert.Len(t, observer.runs[1].Migrations, 2)
+	assert.Empty(t, observer.runs[2].Migrations)
+}
+
+func TestObserverLockWait(t *testing.T) {
+	db := memory.Get(t.Name())
+	db.Reset()
+
+	fsys := fstest.MapFS{
+		"1_users.sql": {Data: []byte(migrationContent("CREATE TABLE users;", "DROP TABLE users;"))},
+	}
+	observer := &recordingObserver{}
+
+	migrator, err := Open(
+		WithDSN(memory.DSN(t.Name())),
+		WithFS(fsys),
+		WithLockTimeout(150*time.Millisecond),
+		WithHooks(observer),
+	)
+	require.NoError(t, err)
+	require.NoError(t, migrator.lock())
+
+	require.ErrorIs(t, migrator.Up(), database.ErrLocked)
+	assert.Positive(t, observer.lockWaits)
+	assert.Empty(t, observer.events)
+}
@@ -0,0 +1,293 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/EvgenyRomanov/sql-migrator/internal/parser"
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database"
+)
+
+var (
+	ErrInvalidOptions    = errors.New("invalid migrator options")
+	ErrUndefinedVariable = parser.ErrUndefinedVariable
+)
+
+// Logger receives informational messages of migrator (e.g. "Migration ... applied").
+type Logger interface {
+	Info(msg string, params ...any)
+}
+
+// Option configures migrator created by Open.
+type Option func(*options)
+
+type options struct {
+	// Sources of connection, exactly one of them should be set.
+	dsn             string
+	driver          database.Driver
+	driverTableName string
+	db              *sql.DB
+	dialect         string
+
+	tableName      string
+	seedsTableName string
+	schema         string
+	dir            string
+	fsys           fs.FS
+	filePattern    string
+	schemaFile     string
+	log            Logger
+	lockTimeout    time.Duration
+	outOfOrder     bool
+	observers      []Observer
+
+	variables       map[string]string
+	strictVariables bool
+}
+
+// WithDSN sets DSN of database, driver is selected by its scheme.
+func WithDSN(dsn string) Option {
+	return func(o *options) {
+		o.dsn = dsn
+	}
+}
+
+// WithDriver sets already opened driver and name of migrations table it was opened with
+// (DefaultTableName if empty), driver is closed by Close of migrator.
+// WithTableName and WithSchema can't be used with it.
+func WithDriver(driver database.Driver, tableName string) Option {
+	return func(o *options) {
+		o.driver = driver
+		o.driverTableName = tableName
+	}
+}
+
+// WithDB sets database connection of application, dialect is name of driver (e.g. "postgres", "mysql").
+// Connection isn't closed by Close of migrator.
+func WithDB(db *sql.DB, dialect string) Option {
+	return func(o *options) {
+		o.db = db
+		o.dialect = dialect
+	}
+}
+
+// WithTableName sets name of migrations table (DefaultTableName by default).
+func WithTableName(tableName string) Option {
+	return func(o *options) {
+		o.tableName = tableName
+	}
+}
+
+// WithSeedsTableName sets name of seeds table (DefaultSeedsTableName by default),
+// which is not a part of application schema read by Schema, Drift and Diff.
+func WithSeedsTableName(tableName string) Option {
+	return func(o *options) {
+		o.seedsTableName = tableName
+	}
+}
+
+// WithSchema sets schema of migrations and seeds tables.
+func WithSchema(schema string) Option {
+	return func(o *options) {
+		o.schema = schema
+	}
+}
+
+// WithDir sets directory of migration files.
+func WithDir(dir string) Option {
+	return func(o *options) {
+		o.dir = dir
+	}
+}
+
+// WithFS sets file system with migration files in its root (e.g. embed.FS or fs.Sub of it).
+// Migrator with file system can't rename files, so Fix isn't supported by it.
+func WithFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.fsys = fsys
+	}
+}
+
+// WithFilePattern sets regular expression for names of migration files (DefaultFilePattern by default).
+func WithFilePattern(pattern string) Option {
+	return func(o *options) {
+		o.filePattern = pattern
+	}
+}
+
+// WithSchemaFile sets path of file to write schema snapshot to after up, down and redo.
+func WithSchemaFile(path string) Option {
+	return func(o *options) {
+		o.schemaFile = path
+	}
+}
+
+// WithLogger sets logger of migrator.
+func WithLogger(log Logger) Option {
+	return func(o *options) {
+		o.log = log
+	}
+}
+
+// WithLockTimeout sets how long to wait for lock held by another process,
+// by default migrator fails immediately with database.ErrLocked.
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.lockTimeout = timeout
+	}
+}
+
+// WithAllowOutOfOrder sets whether up applies migrations with versions lower than the latest applied one
+// (e.g. merged from parallel branch). It is allowed by default, otherwise up fails with ErrOutOfOrder.
+func WithAllowOutOfOrder(allow bool) Option {
+	return func(o *options) {
+		o.outOfOrder = allow
+	}
+}
+
+// WithHooks adds observers of migrations (called in order of adding, after logger).
+func WithHooks(observers ...Observer) Option {
+	return func(o *options) {
+		o.observers = append(o.observers, observers...)
+	}
+}
+
+// WithVariables sets values of placeholders "${name}" and "{{ .Name }}" in SQL of migrations
+// and callback files. Names are matched ignoring case and underscores (app_user is {{ .AppUser }}).
+// Placeholders are replaced when SQL is executed and checksums are calculated from SQL with placeholders,
+// so change of variable doesn't re-run repeatable migrations.
+func WithVariables(variables map[string]string) Option {
+	return func(o *options) {
+		o.variables = variables
+	}
+}
+
+// WithStrictVariables makes migration with undefined placeholder fail with ErrUndefinedVariable,
+// by default such placeholders are kept as is.
+func WithStrictVariables(strict bool) Option {
+	return func(o *options) {
+		o.strictVariables = strict
+	}
+}
+
+// Open returns migrator configured by options. Exactly one of WithDSN, WithDriver and WithDB is required.
+func Open(opts ...Option) (*Migrate, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := o.openDriver()
+	if err != nil {
+		return nil, err
+	}
+
+	migrate := &Migrate{
+		Log:         o.log,
+		SchemaFile:  o.schemaFile,
+		driver:      driver,
+		dsn:         o.dsn,
+		tableName:   o.tableName,
+		seedsTable:  o.seedsTableName,
+		dir:         o.dir,
+		fsys:        o.fsys,
+		lockTimeout: o.lockTimeout,
+		outOfOrder:  o.outOfOrder,
+		observers:   o.observers,
+
+		variables:       o.variables,
+		strictVariables: o.strictVariables,
+	}
+
+	if err := migrate.SetFilePattern(o.filePattern); err != nil {
+		o.closeDriver(driver)
+		return nil, err
+	}
+
+	// Create table if it does not exist.
+	if err := migrate.prepareDatabase(); err != nil {
+		o.closeDriver(driver)
+		return nil, fmt.Errorf("can't initialize table: %w", database.RedactError(err, o.dsn))
+	}
+
+	return migrate, nil
+}
+
+// Return options with defaults and schema applied to names of tables.
+func newOptions(opts []Option) (*options, error) {
+	o := &options{
+		seedsTableName: DefaultSeedsTableName,
+		outOfOrder:     true,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	// Opened driver already uses its table, migrator shouldn't use another one.
+	if o.driver != nil {
+		if o.tableName != "" || o.schema != "" {
+			return nil, fmt.Errorf("%w: table name and schema of driver are set by WithDriver", ErrInvalidOptions)
+		}
+
+		o.tableName = o.driverTableName
+	}
+
+	if o.tableName == "" {
+		o.tableName = DefaultTableName
+	}
+
+	if o.seedsTableName == "" {
+		o.seedsTableName = DefaultSeedsTableName
+	}
+
+	if o.schema != "" {
+		o.tableName = o.schema + "." + o.tableName
+		o.seedsTableName = o.schema + "." + o.seedsTableName
+	}
+
+	return o, nil
+}
+
+// Get driver from the only set source of connection.
+func (o *options) openDriver() (database.Driver, error) {
+	sources := 0
+	for _, set := range []bool{o.dsn != "", o.driver != nil, o.db != nil} {
+		if set {
+			sources++
+		}
+	}
+
+	if sources != 1 {
+		return nil, fmt.Errorf("%w: exactly one of dsn, driver and db should be set", ErrInvalidOptions)
+	}
+
+	switch {
+	case o.driver != nil:
+		return o.driver, nil
+	case o.db != nil:
+		driver, err := database.OpenDB(o.db, o.dialect, o.tableName)
+		if err != nil {
+			return nil, fmt.Errorf("can't get driver: %w", err)
+		}
+
+		return driver, nil
+	default:
+		driver, err := database.Open(o.dsn, o.tableName)
+		if err != nil {
+			return nil, fmt.Errorf("can't get driver: %w", database.RedactError(err, o.dsn))
+		}
+
+		return driver, nil
+	}
+}
+
+// Close driver opened by Open, driver set by WithDriver is closed by caller on error.
+func (o *options) closeDriver(driver database.Driver) {
+	if o.driver == nil {
+		driver.Close()
+	}
+}
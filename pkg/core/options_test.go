@@ -0,0 +1,149 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testLogger struct {
+	messages []string
+}
+
+func (l *testLogger) Info(msg string, params ...any) {
+	l.messages = append(l.messages, fmt.Sprintf(msg, params...))
+}
+
+func TestOpenOptions(t *testing.T) {
+	db := memory.Get(t.Name())
+	db.Reset()
+
+	fsys := fstest.MapFS{
+		"1_users.sql": {Data: []byte(migrationContent("CREATE TABLE users;", "DROP TABLE users;"))},
+		"readme.md":   {Data: []byte("not a migration")},
+	}
+	log := &testLogger{}
+
+	migrator, err := Open(
+		WithDSN(memory.DSN(t.Name())),
+		WithSchema("app"),
+		WithTableName("history"),
+		WithFS(fsys),
+		WithLogger(log),
+	)
+	require.NoError(t, err)
+	defer migrator.Close()
+
+	require.NoError(t, migrator.Up())
+	assert.Len(t, db.Versions("app.history"), 1)
+	assert.NotEmpty(t, log.messages)
+
+	_, err = migrator.Fix()
+	require.ErrorIs(t, err, ErrFixUnsupported)
+}
+
+func TestOpenInvalidOptions(t *testing.T) {
+	_, err := Open(WithDir(t.TempDir()))
+	require.ErrorIs(t, err, ErrInvalidOptions)
+
+	_, err = Open(WithDSN(memory.DSN(t.Name())), WithDriver(&countingDriver{}, ""))
+	require.ErrorIs(t, err, ErrInvalidOptions)
+
+	// Table of opened driver can't be changed.
+	_, err = Open(WithDriver(&countingDriver{}, "migrations"), WithSchema("service"))
+	require.ErrorIs(t, err, ErrInvalidOptions)
+
+	_, err = Open(WithDriver(&countingDriver{}, "migrations"), WithTableName("other"))
+	require.ErrorIs(t, err, ErrInvalidOptions)
+
+	_, err = Open(WithDSN(memory.DSN(t.Name())), WithFilePattern("("))
+	require.Error(t, err)
+}
+
+func TestOpenLockTimeout(t *testing.T) {
+	db := memory.Get(t.Name())
+	db.Reset()
+
+	fsys := fstest.MapFS{
+		"1_users.sql": {Data: []byte(migrationContent("CREATE TABLE users;", "DROP TABLE users;"))},
+	}
+
+	other, err := Open(WithDSN(memory.DSN(t.Name())), WithFS(fsys))
+	require.NoError(t, err)
+	require.NoError(t, other.lock())
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		other.unlock(nil)
+	}()
+
+	migrator, err := Open(WithDSN(memory.DSN(t.Name())), WithFS(fsys), WithLockTimeout(5*time.Second))
+	require.NoError(t, err)
+
+	require.NoError(t, migrator.Up())
+	assert.Len(t, db.Executed(), 1)
+}
+
+func TestOpenDisallowOutOfOrder(t *testing.T) {
+	db := memory.Get(t.Name())
+	db.Reset()
+
+	fsys := fstest.MapFS{
+		"1_users.sql":  {Data: []byte(migrationContent("CREATE TABLE users;", "DROP TABLE users;"))},
+		"3_orders.sql": {Data: []byte(migrationContent("CREATE TABLE orders;", "DROP TABLE orders;"))},
+	}
+
+	migrator, err := Open(WithDSN(memory.DSN(t.Name())), WithFS(fsys), WithAllowOutOfOrder(false))
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up())
+
+	fsys["2_items.sql"] = &fstest.MapFile{Data: []byte(migrationContent("CREATE TABLE items;", "DROP TABLE items;"))}
+
+	require.ErrorIs(t, migrator.Up(), ErrOutOfOrder)
+	assert.Len(t, db.Executed(), 2)
+	assert.False(t, db.Locked())
+}
+
+func TestOpenVariables(t *testing.T) {
+	db := memory.Get(t.Name())
+	db.Reset()
+
+	fsys := fstest.MapFS{
+		"1_grants.sql": {Data: []byte(migrationContent(
+			"GRANT SELECT ON ${schema}.users TO {{ .AppUser }};",
+			"REVOKE SELECT ON ${schema}.users FROM {{ .AppUser }};",
+		))},
+		CallbackAfterMigrate: {Data: []byte("GRANT USAGE ON SCHEMA ${schema} TO ${readonly};")},
+	}
+
+	strict, err := Open(
+		WithDSN(memory.DSN(t.Name())),
+		WithFS(fsys),
+		WithVariables(map[string]string{"schema": "billing", "app_user": "billing_app"}),
+		WithStrictVariables(true),
+	)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, strict.Up(), ErrUndefinedVariable)
+	assert.Empty(t, db.Versions("migrations"))
+
+	migrator, err := Open(
+		WithDSN(memory.DSN(t.Name())),
+		WithFS(fsys),
+		WithVariables(map[string]string{"schema": "billing", "app_user": "billing_app"}),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, migrator.Up())
+	require.NoError(t, migrator.Down())
+	assert.Equal(t, []string{
+		"GRANT SELECT ON billing.users TO billing_app;\n",
+		"GRANT USAGE ON SCHEMA billing TO ${readonly};",
+		"REVOKE SELECT ON billing.users FROM billing_app;\n",
+	}, db.Executed())
+}
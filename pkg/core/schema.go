@@ -0,0 +1,75 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database"
+	"github.com/EvgenyRomanov/sql-migrator/pkg/schema"
+)
+
+var ErrSchemaUnsupported = errors.New("database driver can't read schema")
+
+// Schema reads current schema of database without tables of migrator.
+func (m *Migrate) Schema() (*schema.Schema, error) {
+	return introspect(m.driver, m.seedsTable)
+}
+
+// Read schema of database by driver, which supports it.
+func introspect(driver database.Driver, ignoredTables ...string) (*schema.Schema, error) {
+	introspector, ok := driver.(database.Introspector)
+	if !ok {
+		return nil, ErrSchemaUnsupported
+	}
+
+	s, err := introspector.Introspect(ignoredTables...)
+	if err != nil {
+		return nil, fmt.Errorf("can't read schema: %w", err)
+	}
+
+	return s, nil
+}
+
+// Write schema snapshot to SchemaFile, if it is set.
+func (m *Migrate) dumpSchema() error {
+	if m.SchemaFile == "" {
+		return nil
+	}
+
+	s, err := m.Schema()
+	if err != nil {
+		return err
+	}
+
+	// Write to temporary file first, so snapshot is never half-written.
+	tmp, err := os.CreateTemp(filepath.Dir(m.SchemaFile), filepath.Base(m.SchemaFile)+".*")
+	if err != nil {
+		return fmt.Errorf("can't write schema: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	// Snapshot is committed with migrations.
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("can't write schema: %w", err)
+	}
+
+	if err := s.Dump(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("can't write schema: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("can't write schema: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), m.SchemaFile); err != nil {
+		return fmt.Errorf("can't write schema: %w", err)
+	}
+
+	m.printLog(fmt.Sprintf("Schema written to %s", m.SchemaFile))
+
+	return nil
+}
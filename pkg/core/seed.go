@@ -0,0 +1,130 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const DefaultSeedsTableName = "seeds"
+
+// Seeds are not versioned, so any SQL file is a seed.
+var seedFilePattern = regexp.MustCompile(`\.sql$`)
+
+// Seeder loads seed data on top of migrations.
+// Seeds are tracked by name in their own table, so they don't pollute versioned history.
+type Seeder struct {
+	Log     Logger
+	migrate *Migrate
+	env     string
+}
+
+func NewSeeder(dsn string, tableName string, dir string, env string) (*Seeder, error) {
+	if tableName == "" {
+		tableName = DefaultSeedsTableName
+	}
+
+	migrate, err := NewMigrator(dsn, tableName, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Seeder{
+		migrate: migrate,
+		env:     env,
+	}, nil
+}
+
+// Seed applies seeds for current environment, which were not applied yet.
+// If name is set, only seed with this name is applied.
+func (s *Seeder) Seed(name string) error {
+	if err := s.migrate.lock(); err != nil {
+		return err
+	}
+
+	seeds, err := s.seedsForRun(name)
+	if err != nil {
+		return s.migrate.unlock(err)
+	}
+
+	for _, seed := range seeds {
+		if err := s.migrate.driver.Run(strings.NewReader(seed.UpSQL)); err != nil {
+			return s.migrate.unlock(fmt.Errorf("can't execute seed %s: %w", seed.Source, err))
+		}
+
+		if err := s.migrate.driver.SetRepeatable(seed.Source, seed.Checksum); err != nil {
+			return s.migrate.unlock(fmt.Errorf("can't save seed %s: %w", seed.Source, err))
+		}
+
+		s.printLog(fmt.Sprintf("Seed %s successfully applied!", seed.Source))
+	}
+
+	return s.migrate.unlock(nil)
+}
+
+// Close seeder API.
+func (s *Seeder) Close() error {
+	return s.migrate.Close()
+}
+
+// Prepare seeds for run: not applied, filtered by environment and name.
+func (s *Seeder) seedsForRun(name string) (Migrations, error) {
+	available, err := s.migrate.readFiles(seedFilePattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(available) == 0 {
+		return nil, ErrNoAvailableMigrations
+	}
+
+	applied, err := s.migrate.repeatableChecksums()
+	if err != nil {
+		return nil, err
+	}
+
+	seeds := make(Migrations, 0)
+	found := false
+	for _, seed := range available {
+		if name != "" && seedName(seed.Source) != name && seed.Source != name {
+			continue
+		}
+
+		if len(seed.Environments) > 0 && !slices.Contains(seed.Environments, s.env) {
+			continue
+		}
+		found = true
+
+		if _, ok := applied[seed.Source]; ok {
+			continue
+		}
+
+		seeds = append(seeds, seed)
+	}
+
+	if name != "" && !found {
+		return nil, fmt.Errorf("no seed %q found for environment %q", name, s.env)
+	}
+
+	if len(seeds) == 0 {
+		return nil, ErrAlreadyUpToDate
+	}
+
+	slices.SortFunc(seeds, func(a, b *Migration) int {
+		return strings.Compare(a.Source, b.Source)
+	})
+
+	return seeds, nil
+}
+
+func (s *Seeder) printLog(msg string) {
+	if s.Log != nil {
+		s.Log.Info(msg) //nolint:govet
+	}
+}
+
+// Name of seed is file name without extension.
+func seedName(source string) string {
+	return strings.TrimSuffix(source, ".sql")
+}
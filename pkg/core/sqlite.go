@@ -0,0 +1,4 @@
+package core
+
+// SQLite driver requires cgo, without it driver reports that binary is built without cgo.
+import _ "github.com/EvgenyRomanov/sql-migrator/internal/database/sqlite"
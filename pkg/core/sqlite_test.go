@@ -0,0 +1,70 @@
+//go:build cgo
+
+package core
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateSQLite(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"1_users.sql":  "-- +gomigrator Up\nCREATE TABLE users (id INTEGER);\n-- +gomigrator Down\nDROP TABLE users;\n",
+		"2_orders.sql": "-- +gomigrator Up\nCREATE TABLE orders (id INTEGER);\n-- +gomigrator Down\nDROP TABLE orders;\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	migrator, err := NewMigrator("sqlite://"+filepath.Join(t.TempDir(), "app.db"), "migrations", dir)
+	require.NoError(t, err)
+	defer migrator.Close()
+
+	require.NoError(t, migrator.Up())
+	require.ErrorIs(t, migrator.Up(), ErrAlreadyUpToDate)
+
+	version, err := migrator.DBVersion()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), version)
+
+	require.NoError(t, migrator.Down())
+
+	migrations, err := migrator.Status()
+	require.NoError(t, err)
+	require.Len(t, migrations, 1)
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, database.StatusApplied, migrations[0].Status)
+
+	// Table of rolled back migration is dropped, so it can be applied again.
+	require.NoError(t, migrator.Up())
+}
+
+func TestNewMigratorFromDB(t *testing.T) {
+	dir := t.TempDir()
+	content := "-- +gomigrator Up\nCREATE TABLE users (id INTEGER);\n-- +gomigrator Down\nDROP TABLE users;\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "1_users.sql"), []byte(content), 0o600))
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "app.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrator, err := NewMigratorFromDB(db, "sqlite", "migrations", dir)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up())
+	require.NoError(t, migrator.Close())
+
+	// Connection of application is still open.
+	var count int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM users").Scan(&count))
+	assert.Equal(t, 0, count)
+
+	_, err = NewMigratorFromDB(db, "memory", "migrations", dir)
+	require.ErrorIs(t, err, database.ErrNoDBSupport)
+}
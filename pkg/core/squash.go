@@ -0,0 +1,216 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database"
+)
+
+// BaselineSuffix is suffix of file names of baseline migrations made by Squash.
+const BaselineSuffix = "_baseline.sql"
+
+var (
+	ErrNothingToSquash = errors.New("nothing to squash")
+	ErrPartialBaseline = errors.New("squashed migrations are applied partially")
+)
+
+// Squashed describes baseline migration made by Squash.
+type Squashed struct {
+	// Name of baseline file.
+	File string
+
+	// Files of squashed migrations.
+	Sources []string
+}
+
+// Squash concatenates migrations with versions up to until into one baseline migration.
+// Original files are moved to archiveDir or removed, if archiveDir is empty.
+// On failure original files are restored and baseline isn't created.
+// Baseline takes version of the last squashed migration.
+func Squash(dir string, pattern string, until int64, archiveDir string) (*Squashed, error) {
+	available, err := Discover(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	squashed := make(Migrations, 0)
+	for _, migration := range available {
+		if !migration.Repeatable && migration.Version <= until {
+			squashed = append(squashed, migration)
+		}
+	}
+
+	if len(squashed) == 0 {
+		return nil, fmt.Errorf("%w: no migrations with version up to %d", ErrNothingToSquash, until)
+	}
+
+	// Squashed migrations can't depend on the rest.
+	squashed, err = sortByDependencies(squashed)
+	if err != nil {
+		return nil, fmt.Errorf("can't squash migrations: %w", err)
+	}
+
+	last := squashed[0]
+	versions := make([]string, 0, len(squashed))
+	for _, migration := range squashed {
+		if migration.Version > last.Version {
+			last = migration
+		}
+
+		// Baseline of baseline includes everything squashed before.
+		for _, version := range migration.Baseline {
+			versions = append(versions, strconv.FormatInt(version, 10))
+		}
+		versions = append(versions, strconv.FormatInt(migration.Version, 10))
+	}
+
+	// Keep format of version (e.g. leading zeros of sequential numbering).
+	name := strings.SplitN(last.Source, "_", 2)[0] + BaselineSuffix
+
+	var content strings.Builder
+	content.WriteString("-- +gomigrator Baseline: " + strings.Join(versions, ", ") + "\n")
+	content.WriteString("-- +gomigrator Up\n")
+	for _, migration := range squashed {
+		content.WriteString("-- " + migration.Source + "\n")
+		content.WriteString(strings.TrimSpace(migration.UpSQL) + "\n\n")
+	}
+
+	content.WriteString("-- +gomigrator Down\n")
+	for i := len(squashed) - 1; i >= 0; i-- {
+		content.WriteString("-- " + squashed[i].Source + "\n")
+		content.WriteString(strings.TrimSpace(squashed[i].DownSQL) + "\n\n")
+	}
+
+	// Originals are moved away before baseline is written (baseline of baseline may take its name),
+	// removed ones are kept in temporary dir until baseline is written.
+	target := archiveDir
+	if archiveDir != "" {
+		if err := os.MkdirAll(archiveDir, 0o755); err != nil {
+			return nil, fmt.Errorf("can't create archive dir: %w", err)
+		}
+	} else {
+		if target, err = os.MkdirTemp(dir, ".squash-"); err != nil {
+			return nil, fmt.Errorf("can't create temporary dir: %w", err)
+		}
+		defer os.RemoveAll(target)
+	}
+
+	result := &Squashed{File: name, Sources: make([]string, 0, len(squashed))}
+
+	for _, migration := range squashed {
+		if err := os.Rename(filepath.Join(dir, migration.Source), filepath.Join(target, migration.Source)); err != nil {
+			return nil, restoreSquashed(dir, target, result.Sources, fmt.Errorf("can't archive %s: %w", migration.Source, err))
+		}
+
+		result.Sources = append(result.Sources, migration.Source)
+	}
+
+	if err := writeBaseline(filepath.Join(dir, name), content.String()); err != nil {
+		return nil, restoreSquashed(dir, target, result.Sources, err)
+	}
+
+	return result, nil
+}
+
+// Create baseline file, partially written file is removed.
+func writeBaseline(path string, content string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("can't create baseline migration: %w", err)
+	}
+
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		os.Remove(path)
+		return fmt.Errorf("can't write baseline migration: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("can't write baseline migration: %w", err)
+	}
+
+	return nil
+}
+
+// Move squashed migrations back to migrations dir after failure.
+func restoreSquashed(dir string, target string, sources []string, err error) error {
+	for _, source := range sources {
+		if errRestore := os.Rename(filepath.Join(target, source), filepath.Join(dir, source)); errRestore != nil {
+			return fmt.Errorf("%w; can't restore %s: %w", err, source, errRestore)
+		}
+	}
+
+	return err
+}
+
+// Mark baselines as applied, if all migrations squashed into them are already applied.
+func (m *Migrate) applyBaselines() error {
+	available, err := m.findAvailableMigrations()
+	if err != nil {
+		return err
+	}
+
+	list, err := m.list()
+	if err != nil {
+		return err
+	}
+
+	applied := make(map[int64]bool, len(list))
+	for _, info := range list {
+		if info.Status == database.StatusApplied {
+			applied[info.Version] = true
+		}
+	}
+
+	for _, migration := range available {
+		if len(migration.Baseline) == 0 {
+			continue
+		}
+
+		count := 0
+		for _, version := range migration.Baseline {
+			if applied[version] {
+				count++
+			}
+		}
+
+		switch count {
+		case 0:
+			// Fresh DB, baseline is run by Up.
+		case len(migration.Baseline):
+			// Baseline takes version of the last squashed migration, so it may be applied already.
+			if applied[migration.Version] {
+				continue
+			}
+
+			if err := m.setVersion(migration.Version); err != nil {
+				return err
+			}
+			m.printLog(fmt.Sprintf("Migration %d marked as applied, squashed migrations are already applied", migration.Version))
+		default:
+			return fmt.Errorf(
+				"%w: %d of %d migrations of %s are applied, apply the rest with the original files first",
+				ErrPartialBaseline, count, len(migration.Baseline), migration.Source,
+			)
+		}
+	}
+
+	return nil
+}
+
+// Delete version of migration and versions squashed into it.
+func (m *Migrate) deleteMigration(migration *Migration) error {
+	for _, version := range migration.Baseline {
+		if err := m.deleteVersion(version); err != nil {
+			return err
+		}
+	}
+
+	return m.deleteVersion(migration.Version)
+}
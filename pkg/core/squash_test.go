@@ -0,0 +1,98 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSquash(t *testing.T) {
+	dir := writeMigrations(t, "0001_users.sql", "0002_orders.sql", "0003_products.sql", "R_views.sql")
+	archive := filepath.Join(t.TempDir(), "archive")
+
+	squashed, err := Squash(dir, "", 2, archive)
+	require.NoError(t, err)
+	assert.Equal(t, "0002_baseline.sql", squashed.File)
+	assert.Equal(t, []string{"0001_users.sql", "0002_orders.sql"}, squashed.Sources)
+
+	assert.FileExists(t, filepath.Join(archive, "0001_users.sql"))
+	assert.FileExists(t, filepath.Join(archive, "0002_orders.sql"))
+
+	migrations, err := Discover(dir, "")
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+
+	baseline := migrations[0]
+	assert.Equal(t, "0002_baseline.sql", baseline.Source)
+	assert.Equal(t, int64(2), baseline.Version)
+	assert.Equal(t, []int64{1, 2}, baseline.Baseline)
+	assert.Contains(t, baseline.UpSQL, "-- 0001_users.sql\nSELECT 1;\n\n-- 0002_orders.sql\nSELECT 1;")
+	assert.Equal(t, "0003_products.sql", migrations[1].Source)
+}
+
+func TestSquashRemovesOriginals(t *testing.T) {
+	dir := writeMigrations(t, "1_users.sql", "2_orders.sql")
+
+	_, err := Squash(dir, "", 1, "")
+	require.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(dir, "1_users.sql"))
+	assert.True(t, os.IsNotExist(err))
+	assert.FileExists(t, filepath.Join(dir, "1_baseline.sql"))
+}
+
+func TestSquashRestoresOnArchiveFailure(t *testing.T) {
+	dir := writeMigrations(t, "1_users.sql", "2_orders.sql", "3_products.sql")
+	archive := t.TempDir()
+
+	// Second file can't be moved into archive.
+	require.NoError(t, os.Mkdir(filepath.Join(archive, "2_orders.sql"), 0o755))
+
+	_, err := Squash(dir, "", 2, archive)
+	require.ErrorContains(t, err, "can't archive 2_orders.sql")
+
+	migrations, err := Discover(dir, "")
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+	assert.Equal(t, "1_users.sql", migrations[0].Source)
+	assert.NoFileExists(t, filepath.Join(archive, "1_users.sql"))
+	assert.NoFileExists(t, filepath.Join(dir, "2_baseline.sql"))
+}
+
+func TestSquashRestoresOnBaselineFailure(t *testing.T) {
+	dir := writeMigrations(t, "1_users.sql", "2_orders.sql")
+
+	// Baseline can't be created.
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "2_baseline.sql"), 0o755))
+
+	_, err := Squash(dir, "", 2, "")
+	require.ErrorContains(t, err, "can't create baseline migration")
+
+	assert.FileExists(t, filepath.Join(dir, "1_users.sql"))
+	assert.FileExists(t, filepath.Join(dir, "2_orders.sql"))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+}
+
+func TestSquashNothing(t *testing.T) {
+	dir := writeMigrations(t, "5_users.sql")
+
+	_, err := Squash(dir, "", 4, "")
+	require.ErrorIs(t, err, ErrNothingToSquash)
+}
+
+func TestSortByDependenciesOnSquashed(t *testing.T) {
+	migrations := Migrations{
+		{Version: 3, Source: "3_orders.sql", Depends: []int64{1}},
+		{Version: 2, Source: "2_baseline.sql", Baseline: []int64{1, 2}},
+	}
+
+	sorted, err := sortByDependencies(migrations)
+	require.NoError(t, err)
+	assert.Equal(t, "2_baseline.sql", sorted[0].Source)
+}
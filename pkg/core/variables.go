@@ -0,0 +1,63 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+
+	"github.com/EvgenyRomanov/sql-migrator/internal/parser"
+)
+
+// Replace placeholders in SQL of migration or callback file by values of variables.
+func (m *Migrate) substitute(source string, script string) (string, error) {
+	result, err := parser.Substitute(script, m.variables, m.strictVariables)
+	if err != nil {
+		return "", fmt.Errorf("can't substitute variables in %s: %w", source, err)
+	}
+
+	return result, nil
+}
+
+// Check that placeholders of migrations, repeatable migrations and callbacks are defined.
+func (m *Migrate) checkVariables(migrations Migrations) error {
+	repeatable, err := m.findRepeatableMigrations()
+	if err != nil {
+		return err
+	}
+
+	scripts := make(map[string]string)
+	for _, migration := range migrations {
+		scripts[migration.Source] = migration.UpSQL
+	}
+	for _, migration := range repeatable {
+		scripts[migration.Source] = migration.UpSQL
+	}
+
+	for _, name := range m.callbacks() {
+		content, err := fs.ReadFile(m.files(), name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("can't read callback %s: %w", name, err)
+		}
+
+		scripts[name] = string(content)
+	}
+
+	sources := make([]string, 0, len(scripts))
+	for source := range scripts {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	errs := make([]error, 0)
+	for _, source := range sources {
+		if _, err := m.substitute(source, scripts[source]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,173 @@
+// Package database contains contract of database drivers and registry of them.
+// Driver of another database is implemented by Driver interface and registered by Register
+// under scheme of its DSN in init function of driver package.
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EvgenyRomanov/sql-migrator/pkg/schema"
+)
+
+var (
+	ErrParseDSN      = fmt.Errorf("can't get driver from dsn string")
+	ErrUnknownDriver = fmt.Errorf("unknown driver")
+	ErrLocked        = fmt.Errorf("can't acquire lock")
+	ErrUnlock        = fmt.Errorf("can't unlock, as not currently locked")
+	ErrNoDBSupport   = fmt.Errorf("driver doesn't support existing database connection")
+)
+
+var driversMu sync.RWMutex
+
+// List of available drivers for application.
+var drivers = make(map[string]Driver)
+
+// Statuses of migration stored in migrations table.
+const (
+	StatusRunning = "running"
+	StatusApplied = "applied"
+	StatusFailed  = "failed"
+)
+
+type ListInfo struct {
+	Version   int64
+	AppliedAt time.Time
+	Status    string
+	Error     string
+}
+
+type RepeatableInfo struct {
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+type Driver interface {
+	// Open returns a new driver instance configured with parameters
+	// coming from the URL string. Migrate will call this function
+	// only once per instance.
+	Open(url string, tableName string) (Driver, error)
+
+	// Close closes the underlying database instance managed by the driver.
+	// Migrate will call this function only once per instance.
+	Close() error
+
+	// Lock should acquire a database lock so that only one migration process
+	// can run at a time. Migrate will call this function before Run is called.
+	// If the implementation can't provide this functionality, return nil.
+	// Return database.ErrLocked if database is already locked.
+	Lock() error
+
+	// Unlock should release the lock. Migrate will call this function after
+	// all migrations have been run.
+	Unlock() error
+
+	// Run applies a migration to the database. Migration is guaranteed to be not nil.
+	Run(migration io.Reader) error
+
+	// SetVersion saves version as successfully applied.
+	// Migrate will call this function after each successful call to Run.
+	SetVersion(version int64) error
+
+	// SetStatus saves version with the given status and error message.
+	// Migrate will call this function with StatusRunning before Run
+	// and with StatusFailed if Run returns an error.
+	SetStatus(version int64, status string, message string) error
+
+	// DeleteVersion removes version.
+	// Migrate will call this function before and after each call to Run.
+	DeleteVersion(version int64) error
+
+	// Version returns the currently active version (the last one with StatusApplied).
+	// When no migration has been applied, it must return version -1.
+	Version() (version int64, err error)
+
+	// List returns the slice of all tracked versions of migrations with their statuses.
+	// When no migration has been applied, it must return empty slice.
+	List() (versions []*ListInfo, err error)
+
+	// SetRepeatable saves checksum of the applied repeatable migration with the given name.
+	SetRepeatable(name string, checksum string) error
+
+	// ListRepeatable returns the slice of all applied repeatable migrations.
+	// When no repeatable migration has been applied, it must return empty slice.
+	ListRepeatable() (migrations []*RepeatableInfo, err error)
+
+	// PrepareTable just create table.
+	PrepareTable() error
+}
+
+// Introspector is implemented by drivers, which can read schema of database from its catalog.
+type Introspector interface {
+	// Introspect returns sequences, tables, views and functions of user schemas
+	// except of migrations table itself and ignored tables (e.g. other tables of migrator).
+	Introspect(ignoredTables ...string) (*schema.Schema, error)
+}
+
+// DBOpener is implemented by drivers, which can work on top of *sql.DB opened by application.
+type DBOpener interface {
+	// OpenDB returns a new driver instance, which uses db.
+	// Close of such instance doesn't close db, it is owned by application.
+	OpenDB(db *sql.DB, tableName string) (Driver, error)
+}
+
+// Register globally registers a driver.
+func Register(name string, driver Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if driver == nil {
+		panic("Register driver is nil")
+	}
+
+	if _, dup := drivers[name]; dup {
+		panic("Register called twice for driver " + name)
+	}
+
+	drivers[name] = driver
+}
+
+// Open returns a new driver instance.
+func Open(url string, tableName string) (Driver, error) {
+	i := strings.Index(url, ":")
+
+	if i < 0 {
+		return nil, ErrParseDSN
+	}
+
+	scheme := url[0:i]
+
+	driversMu.RLock()
+	d, ok := drivers[scheme]
+	driversMu.RUnlock()
+
+	if !ok {
+		return nil, ErrUnknownDriver
+	}
+
+	return d.Open(url, tableName)
+}
+
+// OpenDB returns a new driver instance on top of existing database connection.
+// Dialect is name of registered driver (e.g. "postgres", "mysql").
+func OpenDB(db *sql.DB, dialect string, tableName string) (Driver, error) {
+	driversMu.RLock()
+	d, ok := drivers[dialect]
+	driversMu.RUnlock()
+
+	if !ok {
+		return nil, ErrUnknownDriver
+	}
+
+	opener, ok := d.(DBOpener)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoDBSupport, dialect)
+	}
+
+	return opener.OpenDB(db, tableName)
+}
@@ -1,6 +1,8 @@
 package database
 
 import (
+	"database/sql"
+	"errors"
 	"io"
 	"testing"
 )
@@ -37,6 +39,10 @@ func (t *testDriver) SetVersion(_ int64) error {
 	return nil
 }
 
+func (t *testDriver) SetStatus(_ int64, _ string, _ string) error {
+	return nil
+}
+
 func (t *testDriver) DeleteVersion(_ int64) error {
 	return nil
 }
@@ -49,6 +55,14 @@ func (t *testDriver) List() (_ []*ListInfo, err error) {
 	return make([]*ListInfo, 0), nil
 }
 
+func (t *testDriver) SetRepeatable(_ string, _ string) error {
+	return nil
+}
+
+func (t *testDriver) ListRepeatable() (_ []*RepeatableInfo, err error) {
+	return make([]*RepeatableInfo, 0), nil
+}
+
 func (t *testDriver) PrepareTable() error {
 	return nil
 }
@@ -92,3 +106,38 @@ func TestOpen(t *testing.T) {
 		})
 	}
 }
+
+type testDBDriver struct {
+	testDriver
+	db *sql.DB
+}
+
+func (t *testDBDriver) OpenDB(db *sql.DB, tableName string) (Driver, error) {
+	return &testDBDriver{testDriver: testDriver{tableName: tableName}, db: db}, nil
+}
+
+func TestOpenDB(t *testing.T) {
+	Register("testdb", &testDBDriver{})
+	Register("testnodb", &testDriver{})
+
+	db := &sql.DB{}
+
+	driver, err := OpenDB(db, "testdb", "migrations")
+	if err != nil {
+		t.Fatalf("did not expect %q", err)
+	}
+
+	if md, ok := driver.(*testDBDriver); !ok {
+		t.Fatalf("expected *testDBDriver got %T", driver)
+	} else if md.db != db || md.tableName != "migrations" {
+		t.Fatal("expected driver on top of the given database")
+	}
+
+	if _, err := OpenDB(db, "testnodb", "migrations"); !errors.Is(err, ErrNoDBSupport) {
+		t.Fatalf("expected ErrNoDBSupport got %v", err)
+	}
+
+	if _, err := OpenDB(db, "unknown", "migrations"); !errors.Is(err, ErrUnknownDriver) {
+		t.Fatalf("expected ErrUnknownDriver got %v", err)
+	}
+}
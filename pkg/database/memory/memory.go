@@ -0,0 +1,320 @@
+// Package memory provides in-memory database driver for testing of migration flows without database.
+//
+// Driver is registered for "memory://<name>" DSN. All drivers opened with the same name share one
+// Database, which can be inspected and configured in tests:
+//
+//	db := memory.Get("test")
+//	db.FailOn(20250302201917, errors.New("syntax error"))
+//	migrator, _ := core.NewMigrator(memory.DSN("test"), "migrations", "./migrations")
+//	err := migrator.Up()
+//	executed := db.Executed()
+package memory
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database"
+)
+
+const Scheme = "memory"
+
+var (
+	databasesMu sync.Mutex
+	databases   = make(map[string]*Database)
+)
+
+// Database is a named in-memory database.
+type Database struct {
+	mu sync.Mutex
+
+	locked   bool
+	executed []string
+
+	// Migrations tables by name.
+	tables map[string]*table
+
+	// Injected failures by version and by SQL fragment.
+	versionFailures map[int64]error
+	sqlFailures     map[string]error
+
+	// Version, which is being applied (set by SetStatus with StatusRunning), version 0 is valid too.
+	running    int64
+	hasRunning bool
+}
+
+type table struct {
+	versions   map[int64]*database.ListInfo
+	repeatable map[string]*database.RepeatableInfo
+}
+
+// Driver implements database.Driver on top of Database.
+type Driver struct {
+	db        *Database
+	tableName string
+}
+
+// Init itself.
+func init() {
+	database.Register(Scheme, &Driver{})
+}
+
+// DSN returns DSN of database with the given name.
+func DSN(name string) string {
+	return Scheme + "://" + name
+}
+
+// Get returns database by name, it is created if it doesn't exist.
+func Get(name string) *Database {
+	databasesMu.Lock()
+	defer databasesMu.Unlock()
+
+	db, ok := databases[name]
+	if !ok {
+		db = &Database{}
+		db.Reset()
+		databases[name] = db
+	}
+
+	return db
+}
+
+// Reset removes all data, failures and lock of database.
+func (d *Database) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.locked = false
+	d.executed = nil
+	d.tables = make(map[string]*table)
+	d.versionFailures = make(map[int64]error)
+	d.sqlFailures = make(map[string]error)
+	d.running = 0
+	d.hasRunning = false
+}
+
+// Executed returns all successfully executed SQL in order of execution.
+func (d *Database) Executed() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]string(nil), d.executed...)
+}
+
+// FailOn makes Run return err while migration with the given version is applied.
+func (d *Database) FailOn(version int64, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.versionFailures[version] = err
+}
+
+// FailOnSQL makes Run return err for SQL containing fragment (e.g. for Down sections).
+func (d *Database) FailOnSQL(fragment string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.sqlFailures[fragment] = err
+}
+
+// ClearFailures removes failures set by FailOn and FailOnSQL, data of database is kept.
+func (d *Database) ClearFailures() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.versionFailures = make(map[int64]error)
+	d.sqlFailures = make(map[string]error)
+}
+
+// Locked reports whether database is locked.
+func (d *Database) Locked() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.locked
+}
+
+// Versions returns tracked versions of migrations table sorted by version.
+func (d *Database) Versions(tableName string) []database.ListInfo {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	versions := make([]database.ListInfo, 0)
+	for _, info := range d.table(tableName).versions {
+		versions = append(versions, *info)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+
+	return versions
+}
+
+// Get table by name, it is created if it doesn't exist. Mutex should be locked.
+func (d *Database) table(name string) *table {
+	t, ok := d.tables[name]
+	if !ok {
+		t = &table{
+			versions:   make(map[int64]*database.ListInfo),
+			repeatable: make(map[string]*database.RepeatableInfo),
+		}
+		d.tables[name] = t
+	}
+
+	return t
+}
+
+func (m *Driver) Open(url string, tableName string) (database.Driver, error) {
+	name := strings.TrimPrefix(strings.TrimPrefix(url, Scheme+":"), "//")
+	if name == "" {
+		return nil, fmt.Errorf("%w: name of memory database is not set", database.ErrParseDSN)
+	}
+
+	return &Driver{db: Get(name), tableName: tableName}, nil
+}
+
+func (m *Driver) Close() error {
+	return nil
+}
+
+func (m *Driver) Lock() error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if m.db.locked {
+		return database.ErrLocked
+	}
+	m.db.locked = true
+
+	return nil
+}
+
+func (m *Driver) Unlock() error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if !m.db.locked {
+		return database.ErrUnlock
+	}
+	m.db.locked = false
+
+	return nil
+}
+
+// Run records SQL or returns injected failure.
+func (m *Driver) Run(migration io.Reader) error {
+	query, err := io.ReadAll(migration)
+	if err != nil {
+		return err
+	}
+
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if err, ok := m.db.versionFailures[m.db.running]; ok && m.db.hasRunning {
+		return err
+	}
+
+	for fragment, err := range m.db.sqlFailures {
+		if strings.Contains(string(query), fragment) {
+			return err
+		}
+	}
+
+	m.db.executed = append(m.db.executed, string(query))
+
+	return nil
+}
+
+func (m *Driver) SetVersion(version int64) error {
+	return m.SetStatus(version, database.StatusApplied, "")
+}
+
+func (m *Driver) SetStatus(version int64, status string, message string) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	m.db.running, m.db.hasRunning = version, status == database.StatusRunning
+
+	m.db.table(m.tableName).versions[version] = &database.ListInfo{
+		Version:   version,
+		AppliedAt: time.Now(),
+		Status:    status,
+		Error:     message,
+	}
+
+	return nil
+}
+
+func (m *Driver) DeleteVersion(version int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	delete(m.db.table(m.tableName).versions, version)
+
+	return nil
+}
+
+// Version returns the last applied version or -1.
+func (m *Driver) Version() (int64, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	version := int64(-1)
+	for _, info := range m.db.table(m.tableName).versions {
+		if info.Status == database.StatusApplied && info.Version > version {
+			version = info.Version
+		}
+	}
+
+	return version, nil
+}
+
+func (m *Driver) List() ([]*database.ListInfo, error) {
+	versions := m.db.Versions(m.tableName)
+
+	list := make([]*database.ListInfo, 0, len(versions))
+	for i := range versions {
+		list = append(list, &versions[i])
+	}
+
+	return list, nil
+}
+
+func (m *Driver) SetRepeatable(name string, checksum string) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	m.db.table(m.tableName).repeatable[name] = &database.RepeatableInfo{
+		Name:      name,
+		Checksum:  checksum,
+		AppliedAt: time.Now(),
+	}
+
+	return nil
+}
+
+func (m *Driver) ListRepeatable() ([]*database.RepeatableInfo, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	list := make([]*database.RepeatableInfo, 0)
+	for _, info := range m.db.table(m.tableName).repeatable {
+		copied := *info
+		list = append(list, &copied)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list, nil
+}
+
+func (m *Driver) PrepareTable() error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	m.db.table(m.tableName)
+
+	return nil
+}
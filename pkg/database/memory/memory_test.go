@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func open(t *testing.T) (database.Driver, *Database) {
+	t.Helper()
+
+	db := Get(t.Name())
+	db.Reset()
+
+	driver, err := database.Open(DSN(t.Name()), "migrations")
+	require.NoError(t, err)
+
+	return driver, db
+}
+
+func TestOpen(t *testing.T) {
+	_, err := database.Open("memory://", "migrations")
+	require.ErrorIs(t, err, database.ErrParseDSN)
+}
+
+func TestLock(t *testing.T) {
+	driver, db := open(t)
+
+	other, err := database.Open(DSN(t.Name()), "migrations")
+	require.NoError(t, err)
+
+	require.NoError(t, driver.Lock())
+	assert.True(t, db.Locked())
+	require.ErrorIs(t, other.Lock(), database.ErrLocked)
+
+	require.NoError(t, driver.Unlock())
+	require.ErrorIs(t, driver.Unlock(), database.ErrUnlock)
+	require.NoError(t, other.Lock())
+}
+
+func TestVersions(t *testing.T) {
+	driver, db := open(t)
+
+	version, err := driver.Version()
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1), version)
+
+	require.NoError(t, driver.SetVersion(2))
+	require.NoError(t, driver.SetVersion(1))
+	require.NoError(t, driver.SetStatus(3, database.StatusFailed, "error"))
+
+	version, err = driver.Version()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), version)
+
+	list, err := driver.List()
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	assert.Equal(t, int64(1), list[0].Version)
+	assert.Equal(t, database.StatusFailed, list[2].Status)
+
+	require.NoError(t, driver.DeleteVersion(2))
+	assert.Len(t, db.Versions("migrations"), 2)
+
+	// Tables are separate.
+	assert.Empty(t, db.Versions("other"))
+}
+
+func TestRunFailures(t *testing.T) {
+	driver, db := open(t)
+	errVersion := errors.New("version failure")
+	errSQL := errors.New("sql failure")
+
+	db.FailOn(2, errVersion)
+	db.FailOnSQL("DROP", errSQL)
+
+	require.NoError(t, driver.SetStatus(1, database.StatusRunning, ""))
+	require.NoError(t, driver.Run(strings.NewReader("SELECT 1;")))
+
+	require.NoError(t, driver.SetStatus(2, database.StatusRunning, ""))
+	require.ErrorIs(t, driver.Run(strings.NewReader("SELECT 2;")), errVersion)
+
+	require.NoError(t, driver.SetStatus(2, database.StatusFailed, ""))
+	require.NoError(t, driver.Run(strings.NewReader("SELECT 3;")))
+	require.ErrorIs(t, driver.Run(strings.NewReader("DROP TABLE users;")), errSQL)
+
+	assert.Equal(t, []string{"SELECT 1;", "SELECT 3;"}, db.Executed())
+
+	db.ClearFailures()
+	require.NoError(t, driver.SetStatus(2, database.StatusRunning, ""))
+	require.NoError(t, driver.Run(strings.NewReader("DROP TABLE users;")))
+	assert.Len(t, db.Versions("migrations"), 2)
+}
+
+func TestRunFailureOfZeroVersion(t *testing.T) {
+	driver, db := open(t)
+	errVersion := errors.New("version failure")
+
+	db.FailOn(0, errVersion)
+
+	// Nothing is applied, so failure of version 0 isn't triggered.
+	require.NoError(t, driver.Run(strings.NewReader("SELECT 1;")))
+
+	require.NoError(t, driver.SetStatus(0, database.StatusRunning, ""))
+	require.ErrorIs(t, driver.Run(strings.NewReader("SELECT 2;")), errVersion)
+
+	require.NoError(t, driver.SetStatus(0, database.StatusFailed, ""))
+	require.NoError(t, driver.Run(strings.NewReader("SELECT 3;")))
+	assert.Equal(t, []string{"SELECT 1;", "SELECT 3;"}, db.Executed())
+}
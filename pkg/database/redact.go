@@ -0,0 +1,69 @@
+package database
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Replacement for secrets.
+const redacted = "xxxxx"
+
+// Password in key=value DSN (password=secret or password='secret').
+var passwordRe = regexp.MustCompile(`password=('(\\'|[^'])*'|\S+)`)
+
+// RedactDSN hides password in DSN string, so it can be logged.
+func RedactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+
+	return passwordRe.ReplaceAllString(dsn, "password="+redacted)
+}
+
+// Password returns password from DSN string or empty string if there is no password.
+func Password(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		password, _ := u.User.Password()
+		return password
+	}
+
+	match := passwordRe.FindStringSubmatch(dsn)
+	if match == nil {
+		return ""
+	}
+
+	return strings.Trim(match[1], "'")
+}
+
+// RedactError hides DSN and its password in error message, keeping the original error for errors.Is.
+func RedactError(err error, dsn string) error {
+	if err == nil || dsn == "" {
+		return err
+	}
+
+	msg := strings.ReplaceAll(err.Error(), dsn, RedactDSN(dsn))
+	if password := Password(dsn); password != "" {
+		msg = strings.ReplaceAll(msg, password, redacted)
+		msg = strings.ReplaceAll(msg, url.PathEscape(password), redacted)
+	}
+
+	if msg == err.Error() {
+		return err
+	}
+
+	return &redactedError{msg: msg, err: err}
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,201 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Types of serial columns.
+var serialTypes = map[string]string{
+	"smallserial": "smallint",
+	"serial":      "integer",
+	"bigserial":   "bigint",
+}
+
+// Phases of changes: objects are dropped before their dependencies and created after them,
+// sequences are dropped after defaults of columns, which use them.
+const (
+	phaseDropViews = iota
+	phaseDropFunctions
+	phaseDropForeignKeys
+	phaseDropConstraints
+	phaseDropIndexes
+	phaseDropColumns
+	phaseDropTables
+	phaseCreateSequences
+	phaseCreateTables
+	phaseAddColumns
+	phaseAlterColumns
+	phaseDropSequences
+	phaseCreateFunctions
+	phaseAddConstraints
+	phaseAddForeignKeys
+	phaseCreateIndexes
+	phaseCreateViews
+	phasesCount
+)
+
+// Changes returns SQL statements, which change schema from one state to another.
+func Changes(from *Schema, to *Schema) []string {
+	phases := make([][]string, phasesCount)
+	add := func(phase int, statement string) {
+		phases[phase] = append(phases[phase], statement)
+	}
+
+	for _, diff := range Compare(to, from) {
+		create := diff.Kind == Missing || diff.Kind == Changed
+		drop := diff.Kind == Unexpected || diff.Kind == Changed
+
+		switch diff.Object {
+		case ObjectSequence:
+			switch diff.Kind {
+			case Missing:
+				add(phaseCreateSequences, diff.Expected)
+			case Unexpected:
+				add(phaseDropSequences, fmt.Sprintf("DROP SEQUENCE %s;", diff.Name))
+			default:
+				// Sequence is altered, so defaults using it are kept.
+				add(phaseCreateSequences, fmt.Sprintf("ALTER SEQUENCE %s %s;", diff.Name, to.Sequence(diff.Name).Definition))
+			}
+		case ObjectTable:
+			if create {
+				add(phaseCreateTables, diff.Expected)
+			}
+			if drop {
+				add(phaseDropTables, fmt.Sprintf("DROP TABLE %s;", diff.Name))
+			}
+		case ObjectColumn:
+			switch diff.Kind {
+			case Missing:
+				add(phaseAddColumns, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", diff.Table, diff.Expected))
+			case Unexpected:
+				add(phaseDropColumns, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", diff.Table, diff.Name))
+			default:
+				for _, statement := range alterColumn(
+					diff.Table, to.Table(diff.Table).Column(diff.Name), from.Table(diff.Table).Column(diff.Name),
+				) {
+					add(phaseAlterColumns, statement)
+				}
+			}
+		case ObjectConstraint:
+			foreignKey := strings.Contains(diff.Expected+diff.Actual, "FOREIGN KEY")
+			if drop {
+				phase := phaseDropConstraints
+				if foreignKey {
+					phase = phaseDropForeignKeys
+				}
+				add(phase, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", diff.Table, diff.Name))
+			}
+			if create {
+				phase := phaseAddConstraints
+				if foreignKey {
+					phase = phaseAddForeignKeys
+				}
+				add(phase, diff.Expected)
+			}
+		case ObjectIndex:
+			if drop {
+				add(phaseDropIndexes, fmt.Sprintf("DROP INDEX %s;", qualifiedIndexName(diff.Table, diff.Name)))
+			}
+			if create {
+				add(phaseCreateIndexes, diff.Expected)
+			}
+		case ObjectView:
+			if drop {
+				add(phaseDropViews, fmt.Sprintf("DROP VIEW %s;", diff.Name))
+			}
+			if create {
+				add(phaseCreateViews, diff.Expected)
+			}
+		case ObjectFunction:
+			// Definition of function is CREATE OR REPLACE statement.
+			if diff.Kind == Unexpected {
+				add(phaseDropFunctions, fmt.Sprintf("DROP FUNCTION %s;", diff.Name))
+			} else {
+				add(phaseCreateFunctions, diff.Expected+";")
+			}
+		}
+	}
+
+	statements := make([]string, 0)
+	for _, phase := range phases {
+		statements = append(statements, phase...)
+	}
+
+	return statements
+}
+
+func alterColumn(table string, to *Column, from *Column) []string {
+	prefix := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s", table, to.Name)
+	statements := make([]string, 0)
+
+	toType, toSerial := serialTypes[to.Type]
+	if !toSerial {
+		toType = to.Type
+	}
+
+	fromType, fromSerial := serialTypes[from.Type]
+	if !fromSerial {
+		fromType = from.Type
+	}
+
+	if toType != fromType {
+		statements = append(statements, fmt.Sprintf("%s TYPE %s;", prefix, toType))
+	}
+
+	if to.Nullable != from.Nullable {
+		if to.Nullable {
+			statements = append(statements, prefix+" DROP NOT NULL;")
+		} else {
+			statements = append(statements, prefix+" SET NOT NULL;")
+		}
+	}
+
+	// Serial column is a column with default from sequence owned by it.
+	sequence := serialSequence(table, to.Name)
+	switch {
+	case toSerial && !fromSerial:
+		statements = append(statements,
+			fmt.Sprintf("CREATE SEQUENCE %s AS %s OWNED BY %s.%s;", sequence, toType, table, to.Name),
+			fmt.Sprintf("%s SET DEFAULT nextval('%s');", prefix, sequence),
+		)
+	case fromSerial && !toSerial:
+		statements = append(statements, alterDefault(prefix, to.Default), fmt.Sprintf("DROP SEQUENCE %s;", sequence))
+	case to.Default != from.Default:
+		statements = append(statements, alterDefault(prefix, to.Default))
+	}
+
+	switch {
+	case to.Identity == from.Identity:
+	case to.Identity == "":
+		statements = append(statements, prefix+" DROP IDENTITY;")
+	case from.Identity == "":
+		statements = append(statements, fmt.Sprintf("%s ADD GENERATED %s AS IDENTITY;", prefix, to.Identity))
+	default:
+		statements = append(statements, fmt.Sprintf("%s SET GENERATED %s;", prefix, to.Identity))
+	}
+
+	return statements
+}
+
+func alterDefault(prefix string, value string) string {
+	if value == "" {
+		return prefix + " DROP DEFAULT;"
+	}
+
+	return fmt.Sprintf("%s SET DEFAULT %s;", prefix, value)
+}
+
+// Name of sequence created for serial column.
+func serialSequence(table string, column string) string {
+	return table + "_" + column + "_seq"
+}
+
+// Indexes are in the same schema as their tables.
+func qualifiedIndexName(table string, index string) string {
+	if i := strings.Index(table, "."); i >= 0 {
+		return table[:i+1] + index
+	}
+
+	return index
+}
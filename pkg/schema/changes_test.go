@@ -0,0 +1,114 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChanges(t *testing.T) {
+	from := &Schema{
+		Tables: []*Table{
+			{
+				Name: "public.users",
+				Columns: []*Column{
+					{Name: "id", Type: "integer"},
+					{Name: "legacy", Type: "text", Nullable: true},
+				},
+				Constraints: []*Constraint{{Name: "users_pkey", Definition: "PRIMARY KEY (id)"}},
+			},
+		},
+	}
+
+	to := &Schema{
+		Tables: []*Table{
+			{
+				Name: "public.users",
+				Columns: []*Column{
+					{Name: "id", Type: "bigint"},
+					{Name: "email", Type: "text", Default: "''::text"},
+				},
+				Constraints: []*Constraint{{Name: "users_pkey", Definition: "PRIMARY KEY (id)"}},
+				Indexes: []*Index{
+					{Name: "users_email_idx", Definition: "CREATE INDEX users_email_idx ON public.users USING btree (email)"},
+				},
+			},
+			{
+				Name:    "public.orders",
+				Columns: []*Column{{Name: "user_id", Type: "bigint"}},
+				Constraints: []*Constraint{
+					{Name: "orders_user_id_fkey", Definition: "FOREIGN KEY (user_id) REFERENCES public.users(id)"},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{
+		"ALTER TABLE public.users DROP COLUMN legacy;",
+		"CREATE TABLE public.orders (\n    user_id bigint NOT NULL\n);",
+		"ALTER TABLE public.users ADD COLUMN email text NOT NULL DEFAULT ''::text;",
+		"ALTER TABLE public.users ALTER COLUMN id TYPE bigint;",
+		"ALTER TABLE public.orders ADD CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id);",
+		"CREATE INDEX users_email_idx ON public.users USING btree (email);",
+	}, Changes(from, to))
+
+	assert.Equal(t, []string{
+		"ALTER TABLE public.orders DROP CONSTRAINT orders_user_id_fkey;",
+		"DROP INDEX public.users_email_idx;",
+		"ALTER TABLE public.users DROP COLUMN email;",
+		"DROP TABLE public.orders;",
+		"ALTER TABLE public.users ADD COLUMN legacy text;",
+		"ALTER TABLE public.users ALTER COLUMN id TYPE integer;",
+	}, Changes(to, from))
+
+	assert.Empty(t, Changes(from, from))
+}
+
+func TestChangesOfSequences(t *testing.T) {
+	from := &Schema{
+		Sequences: []*Sequence{{Name: "public.legacy_numbers", Definition: "AS bigint START WITH 1"}},
+		Tables: []*Table{
+			{
+				Name: "public.users",
+				Columns: []*Column{
+					{Name: "id", Type: "integer"},
+					{Name: "number", Type: "bigint"},
+				},
+			},
+		},
+	}
+
+	to := &Schema{
+		Sequences: []*Sequence{{Name: "public.numbers", Definition: "AS bigint START WITH 100"}},
+		Tables: []*Table{
+			{
+				Name: "public.users",
+				Columns: []*Column{
+					{Name: "id", Type: "serial"},
+					{Name: "number", Type: "bigint", Identity: "BY DEFAULT"},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{
+		"CREATE SEQUENCE public.numbers AS bigint START WITH 100;",
+		"CREATE SEQUENCE public.users_id_seq AS integer OWNED BY public.users.id;",
+		"ALTER TABLE public.users ALTER COLUMN id SET DEFAULT nextval('public.users_id_seq');",
+		"ALTER TABLE public.users ALTER COLUMN number ADD GENERATED BY DEFAULT AS IDENTITY;",
+		"DROP SEQUENCE public.legacy_numbers;",
+	}, Changes(from, to))
+
+	assert.Equal(t, []string{
+		"CREATE SEQUENCE public.legacy_numbers AS bigint START WITH 1;",
+		"ALTER TABLE public.users ALTER COLUMN id DROP DEFAULT;",
+		"DROP SEQUENCE public.users_id_seq;",
+		"ALTER TABLE public.users ALTER COLUMN number DROP IDENTITY;",
+		"DROP SEQUENCE public.numbers;",
+	}, Changes(to, from))
+
+	changed := &Schema{Sequences: []*Sequence{{Name: "public.numbers", Definition: "AS bigint START WITH 200"}}}
+	assert.Equal(t, []string{
+		"ALTER SEQUENCE public.numbers AS bigint START WITH 200;",
+	}, Changes(&Schema{Sequences: to.Sequences}, changed))
+}
@@ -0,0 +1,255 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Kinds of differences.
+const (
+	// Object exists in expected schema only.
+	Missing = "missing"
+
+	// Object exists in actual schema only.
+	Unexpected = "unexpected"
+
+	// Object exists in both schemas with different definitions.
+	Changed = "changed"
+)
+
+// Types of objects.
+const (
+	ObjectSequence   = "sequence"
+	ObjectTable      = "table"
+	ObjectColumn     = "column"
+	ObjectConstraint = "constraint"
+	ObjectIndex      = "index"
+	ObjectView       = "view"
+	ObjectFunction   = "function"
+)
+
+// Difference is an object, which differs in two schemas.
+type Difference struct {
+	Kind   string
+	Object string
+
+	// Qualified name of table for columns, constraints and indexes.
+	Table string
+	Name  string
+
+	// SQL definitions of object in expected and actual schemas (empty if object doesn't exist).
+	Expected string
+	Actual   string
+}
+
+func (d Difference) String() string {
+	name := d.Name
+	if d.Table != "" {
+		name = d.Table + "." + d.Name
+	}
+
+	switch d.Kind {
+	case Missing:
+		return fmt.Sprintf("missing %s %s: %s", d.Object, name, d.Expected)
+	case Unexpected:
+		return fmt.Sprintf("unexpected %s %s: %s", d.Object, name, d.Actual)
+	default:
+		return fmt.Sprintf("changed %s %s: expected %s, actual %s", d.Object, name, d.Expected, d.Actual)
+	}
+}
+
+// Column returns column by name or nil.
+func (t *Table) Column(name string) *Column {
+	for _, column := range t.Columns {
+		if column.Name == name {
+			return column
+		}
+	}
+
+	return nil
+}
+
+// RenameSchema replaces name of schema in names and definitions of objects.
+// It is used to compare objects created in scratch schema with the original ones.
+func (s *Schema) RenameSchema(from string, to string) {
+	rename := func(value string) string {
+		return strings.ReplaceAll(value, from+".", to+".")
+	}
+
+	for _, sequence := range s.Sequences {
+		sequence.Name = rename(sequence.Name)
+	}
+
+	for _, table := range s.Tables {
+		table.Name = rename(table.Name)
+		for _, column := range table.Columns {
+			column.Default = rename(column.Default)
+		}
+		for _, constraint := range table.Constraints {
+			constraint.Definition = rename(constraint.Definition)
+		}
+		for _, index := range table.Indexes {
+			index.Definition = rename(index.Definition)
+		}
+	}
+
+	for _, view := range s.Views {
+		view.Name = rename(view.Name)
+		view.Definition = rename(view.Definition)
+	}
+
+	for _, function := range s.Functions {
+		function.Name = rename(function.Name)
+		function.Definition = rename(function.Definition)
+	}
+}
+
+// Compare returns differences of actual schema from expected one.
+// Constraints and indexes of missing or unexpected tables are reported along with tables.
+func Compare(expected *Schema, actual *Schema) []Difference {
+	sequences := func(s *Schema) map[string]string {
+		definitions := make(map[string]string, len(s.Sequences))
+		for _, sequence := range s.Sequences {
+			definitions[sequence.Name] = sequence.CreateSQL()
+		}
+
+		return definitions
+	}
+	diffs := compareDefinitions(ObjectSequence, "", sequences(expected), sequences(actual))
+
+	for _, table := range expected.Tables {
+		actualTable := actual.Table(table.Name)
+		if actualTable == nil {
+			diffs = append(diffs, Difference{
+				Kind: Missing, Object: ObjectTable, Name: table.Name, Expected: table.CreateSQL(),
+			})
+			actualTable = &Table{Name: table.Name}
+		} else {
+			diffs = append(diffs, compareColumns(table, actualTable)...)
+		}
+
+		diffs = append(diffs, compareTableObjects(table, actualTable)...)
+	}
+
+	for _, table := range actual.Tables {
+		if expected.Table(table.Name) == nil {
+			diffs = append(diffs, Difference{
+				Kind: Unexpected, Object: ObjectTable, Name: table.Name, Actual: table.CreateSQL(),
+			})
+			diffs = append(diffs, compareTableObjects(&Table{Name: table.Name}, table)...)
+		}
+	}
+
+	views := func(s *Schema) map[string]string {
+		definitions := make(map[string]string, len(s.Views))
+		for _, view := range s.Views {
+			definitions[view.Name] = view.CreateSQL()
+		}
+
+		return definitions
+	}
+	diffs = append(diffs, compareDefinitions(ObjectView, "", views(expected), views(actual))...)
+
+	functions := func(s *Schema) map[string]string {
+		definitions := make(map[string]string, len(s.Functions))
+		for _, function := range s.Functions {
+			definitions[function.Name] = strings.TrimSpace(function.Definition)
+		}
+
+		return definitions
+	}
+	diffs = append(diffs, compareDefinitions(ObjectFunction, "", functions(expected), functions(actual))...)
+
+	return diffs
+}
+
+func compareColumns(expected *Table, actual *Table) []Difference {
+	diffs := make([]Difference, 0)
+
+	for _, column := range expected.Columns {
+		actualColumn := actual.Column(column.Name)
+
+		switch {
+		case actualColumn == nil:
+			diffs = append(diffs, Difference{
+				Kind: Missing, Object: ObjectColumn, Table: expected.Name, Name: column.Name, Expected: column.SQL(),
+			})
+		case actualColumn.SQL() != column.SQL():
+			diffs = append(diffs, Difference{
+				Kind: Changed, Object: ObjectColumn, Table: expected.Name, Name: column.Name,
+				Expected: column.SQL(), Actual: actualColumn.SQL(),
+			})
+		}
+	}
+
+	for _, column := range actual.Columns {
+		if expected.Column(column.Name) == nil {
+			diffs = append(diffs, Difference{
+				Kind: Unexpected, Object: ObjectColumn, Table: expected.Name, Name: column.Name, Actual: column.SQL(),
+			})
+		}
+	}
+
+	return diffs
+}
+
+func compareTableObjects(expected *Table, actual *Table) []Difference {
+	constraints := func(t *Table) map[string]string {
+		definitions := make(map[string]string, len(t.Constraints))
+		for _, constraint := range t.Constraints {
+			definitions[constraint.Name] = constraint.CreateSQL(t.Name)
+		}
+
+		return definitions
+	}
+
+	indexes := func(t *Table) map[string]string {
+		definitions := make(map[string]string, len(t.Indexes))
+		for _, index := range t.Indexes {
+			definitions[index.Name] = index.Definition + ";"
+		}
+
+		return definitions
+	}
+
+	diffs := compareDefinitions(ObjectConstraint, expected.Name, constraints(expected), constraints(actual))
+
+	return append(diffs, compareDefinitions(ObjectIndex, expected.Name, indexes(expected), indexes(actual))...)
+}
+
+// Compare objects of one type by names and definitions.
+func compareDefinitions(object string, table string, expected map[string]string, actual map[string]string) []Difference {
+	names := make([]string, 0, len(expected)+len(actual))
+	for name := range expected {
+		names = append(names, name)
+	}
+	for name := range actual {
+		if _, ok := expected[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	diffs := make([]Difference, 0)
+	for _, name := range names {
+		expectedDefinition, inExpected := expected[name]
+		actualDefinition, inActual := actual[name]
+
+		diff := Difference{Object: object, Table: table, Name: name, Expected: expectedDefinition, Actual: actualDefinition}
+		switch {
+		case !inActual:
+			diff.Kind = Missing
+		case !inExpected:
+			diff.Kind = Unexpected
+		case expectedDefinition != actualDefinition:
+			diff.Kind = Changed
+		default:
+			continue
+		}
+
+		diffs = append(diffs, diff)
+	}
+
+	return diffs
+}
@@ -0,0 +1,220 @@
+package schema
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Schema is a database structure read from the database catalog.
+type Schema struct {
+	Sequences []*Sequence
+	Tables    []*Table
+	Views     []*View
+	Functions []*Function
+}
+
+// Sequence is a standalone sequence, sequences of serial and identity columns are parts of columns.
+type Sequence struct {
+	// Qualified name of sequence (schema.sequence).
+	Name string
+
+	// Options of sequence (e.g. "AS bigint INCREMENT BY 1 ... CACHE 1").
+	Definition string
+}
+
+type Table struct {
+	// Qualified name of table (schema.table).
+	Name string
+
+	// Columns in order of their position in table.
+	Columns     []*Column
+	Constraints []*Constraint
+	Indexes     []*Index
+}
+
+type Column struct {
+	Name     string
+	Type     string
+	Nullable bool
+
+	// Default expression, empty if not set.
+	Default string
+
+	// Generation of identity column (ALWAYS or BY DEFAULT), empty if column is not identity.
+	Identity string
+}
+
+type Constraint struct {
+	Name string
+
+	// Definition as it is returned by database (e.g. "PRIMARY KEY (id)").
+	Definition string
+}
+
+type Index struct {
+	Name string
+
+	// Full CREATE INDEX statement.
+	Definition string
+}
+
+type View struct {
+	// Qualified name of view (schema.view).
+	Name string
+
+	// SELECT statement of view.
+	Definition string
+}
+
+type Function struct {
+	// Qualified name of function with types of arguments (schema.func(integer)).
+	Name string
+
+	// Full CREATE FUNCTION statement.
+	Definition string
+}
+
+// Table returns table by qualified name or nil.
+func (s *Schema) Table(name string) *Table {
+	for _, table := range s.Tables {
+		if table.Name == name {
+			return table
+		}
+	}
+
+	return nil
+}
+
+// Sequence returns sequence by qualified name or nil.
+func (s *Schema) Sequence(name string) *Sequence {
+	for _, sequence := range s.Sequences {
+		if sequence.Name == name {
+			return sequence
+		}
+	}
+
+	return nil
+}
+
+// Sort objects by names, columns keep their order.
+func (s *Schema) Sort() {
+	sort.Slice(s.Sequences, func(i, j int) bool { return s.Sequences[i].Name < s.Sequences[j].Name })
+	sort.Slice(s.Tables, func(i, j int) bool { return s.Tables[i].Name < s.Tables[j].Name })
+	sort.Slice(s.Views, func(i, j int) bool { return s.Views[i].Name < s.Views[j].Name })
+	sort.Slice(s.Functions, func(i, j int) bool { return s.Functions[i].Name < s.Functions[j].Name })
+
+	for _, table := range s.Tables {
+		sort.Slice(table.Constraints, func(i, j int) bool { return table.Constraints[i].Name < table.Constraints[j].Name })
+		sort.Slice(table.Indexes, func(i, j int) bool { return table.Indexes[i].Name < table.Indexes[j].Name })
+	}
+}
+
+// Dump writes schema as SQL statements in stable order, so dumps of the same schema are equal.
+func (s *Schema) Dump(w io.Writer) error {
+	s.Sort()
+
+	var b strings.Builder
+	b.WriteString("-- Schema snapshot generated by gomigrator, do not edit.\n")
+
+	// Defaults of columns may use sequences.
+	for _, sequence := range s.Sequences {
+		b.WriteString("\n" + sequence.CreateSQL() + "\n")
+	}
+
+	for _, table := range s.Tables {
+		b.WriteString("\n" + table.CreateSQL() + "\n")
+
+		for _, constraint := range table.Constraints {
+			b.WriteString(constraint.CreateSQL(table.Name) + "\n")
+		}
+
+		for _, index := range table.Indexes {
+			b.WriteString(index.Definition + ";\n")
+		}
+	}
+
+	for _, view := range s.Views {
+		b.WriteString("\n" + view.CreateSQL() + "\n")
+	}
+
+	for _, function := range s.Functions {
+		b.WriteString("\n" + strings.TrimSpace(function.Definition) + ";\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func (t *Table) CreateSQL() string {
+	columns := make([]string, 0, len(t.Columns))
+	for _, column := range t.Columns {
+		columns = append(columns, "    "+column.SQL())
+	}
+
+	return fmt.Sprintf("CREATE TABLE %s (\n%s\n);", t.Name, strings.Join(columns, ",\n"))
+}
+
+// SQL returns definition of column as it is used in CREATE TABLE.
+func (c *Column) SQL() string {
+	definition := c.Name + " " + c.Type
+	if !c.Nullable {
+		definition += " NOT NULL"
+	}
+
+	if c.Default != "" {
+		definition += " DEFAULT " + c.Default
+	}
+
+	if c.Identity != "" {
+		definition += " GENERATED " + c.Identity + " AS IDENTITY"
+	}
+
+	return definition
+}
+
+func (s *Sequence) CreateSQL() string {
+	return fmt.Sprintf("CREATE SEQUENCE %s %s;", s.Name, s.Definition)
+}
+
+func (c *Constraint) CreateSQL(table string) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s;", table, c.Name, c.Definition)
+}
+
+func (v *View) CreateSQL() string {
+	return fmt.Sprintf("CREATE VIEW %s AS\n%s;", v.Name, strings.TrimSuffix(strings.TrimSpace(v.Definition), ";"))
+}
+
+// Filter returns objects of schema with the given name.
+func (s *Schema) Filter(name string) *Schema {
+	prefix := name + "."
+	filtered := &Schema{}
+
+	for _, sequence := range s.Sequences {
+		if strings.HasPrefix(sequence.Name, prefix) {
+			filtered.Sequences = append(filtered.Sequences, sequence)
+		}
+	}
+
+	for _, table := range s.Tables {
+		if strings.HasPrefix(table.Name, prefix) {
+			filtered.Tables = append(filtered.Tables, table)
+		}
+	}
+
+	for _, view := range s.Views {
+		if strings.HasPrefix(view.Name, prefix) {
+			filtered.Views = append(filtered.Views, view)
+		}
+	}
+
+	for _, function := range s.Functions {
+		if strings.HasPrefix(function.Name, prefix) {
+			filtered.Functions = append(filtered.Functions, function)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,131 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDump(t *testing.T) {
+	s := &Schema{
+		Sequences: []*Sequence{{Name: "public.order_numbers", Definition: "AS bigint START WITH 100 CACHE 1"}},
+		Tables: []*Table{
+			{
+				Name: "public.users",
+				Columns: []*Column{
+					{Name: "id", Type: "bigserial"},
+					{Name: "name", Type: "text", Nullable: true},
+				},
+				Constraints: []*Constraint{{Name: "users_pkey", Definition: "PRIMARY KEY (id)"}},
+				Indexes: []*Index{
+					{Name: "users_name_idx", Definition: "CREATE INDEX users_name_idx ON public.users USING btree (name)"},
+				},
+			},
+			{
+				Name: "public.orders",
+				Columns: []*Column{
+					{Name: "id", Type: "bigint", Identity: "ALWAYS"},
+					{Name: "number", Type: "bigint", Default: "nextval('order_numbers'::regclass)"},
+				},
+			},
+		},
+		Views: []*View{{Name: "public.named_users", Definition: " SELECT id FROM users WHERE name IS NOT NULL;"}},
+	}
+
+	var b strings.Builder
+	require.NoError(t, s.Dump(&b))
+
+	expected := `-- Schema snapshot generated by gomigrator, do not edit.
+
+CREATE SEQUENCE public.order_numbers AS bigint START WITH 100 CACHE 1;
+
+CREATE TABLE public.orders (
+    id bigint NOT NULL GENERATED ALWAYS AS IDENTITY,
+    number bigint NOT NULL DEFAULT nextval('order_numbers'::regclass)
+);
+
+CREATE TABLE public.users (
+    id bigserial NOT NULL,
+    name text
+);
+ALTER TABLE public.users ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+CREATE INDEX users_name_idx ON public.users USING btree (name);
+
+CREATE VIEW public.named_users AS
+SELECT id FROM users WHERE name IS NOT NULL;
+`
+	assert.Equal(t, expected, b.String())
+	assert.Equal(t, "public.users", s.Table("public.users").Name)
+	assert.Nil(t, s.Table("public.missing"))
+}
+
+func TestCompare(t *testing.T) {
+	expected := &Schema{
+		Tables: []*Table{
+			{
+				Name: "public.users",
+				Columns: []*Column{
+					{Name: "id", Type: "bigint"},
+					{Name: "email", Type: "text"},
+				},
+				Indexes: []*Index{
+					{Name: "users_email_idx", Definition: "CREATE INDEX users_email_idx ON public.users USING btree (email)"},
+				},
+			},
+		},
+	}
+
+	actual := &Schema{
+		Tables: []*Table{
+			{
+				Name: "public.users",
+				Columns: []*Column{
+					{Name: "id", Type: "integer"},
+					{Name: "email", Type: "text"},
+					{Name: "hotfix", Type: "text", Nullable: true},
+				},
+			},
+			{Name: "public.tmp", Columns: []*Column{{Name: "id", Type: "bigint"}}},
+		},
+	}
+
+	diffs := Compare(expected, actual)
+
+	messages := make([]string, 0, len(diffs))
+	for _, diff := range diffs {
+		messages = append(messages, diff.String())
+	}
+
+	assert.Equal(t, []string{
+		"changed column public.users.id: expected id bigint NOT NULL, actual id integer NOT NULL",
+		"unexpected column public.users.hotfix: hotfix text",
+		"missing index public.users.users_email_idx: CREATE INDEX users_email_idx ON public.users USING btree (email);",
+		"unexpected table public.tmp: CREATE TABLE public.tmp (\n    id bigint NOT NULL\n);",
+	}, messages)
+
+	assert.Empty(t, Compare(expected, expected))
+}
+
+func TestFilterAndRenameSchema(t *testing.T) {
+	s := &Schema{
+		Sequences: []*Sequence{{Name: "scratch.numbers"}, {Name: "public.numbers"}},
+		Tables: []*Table{
+			{
+				Name:    "scratch.users",
+				Indexes: []*Index{{Name: "users_idx", Definition: "CREATE INDEX users_idx ON scratch.users USING btree (id)"}},
+			},
+			{Name: "public.users"},
+		},
+	}
+
+	filtered := s.Filter("scratch")
+	filtered.RenameSchema("scratch", "public")
+
+	require.Len(t, filtered.Sequences, 1)
+	assert.Equal(t, "public.numbers", filtered.Sequences[0].Name)
+	require.Len(t, filtered.Tables, 1)
+	assert.Equal(t, "public.users", filtered.Tables[0].Name)
+	assert.Equal(t, "CREATE INDEX users_idx ON public.users USING btree (id)", filtered.Tables[0].Indexes[0].Definition)
+}
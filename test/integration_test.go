@@ -3,12 +3,16 @@
 package test
 
 import (
+	"database/sql"
 	"fmt"
-	"github.com/EvgenyRomanov/sql-migrator/internal/database"
 	"github.com/EvgenyRomanov/sql-migrator/pkg/core"
+	"github.com/EvgenyRomanov/sql-migrator/pkg/database"
+	"github.com/EvgenyRomanov/sql-migrator/pkg/schema"
 	"github.com/stretchr/testify/suite"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -154,6 +158,156 @@ func (s *MigratorSuite) TestMigratorDBVersion() {
 	s.Equal(lastMigration.Version, version)
 }
 
+func (s *MigratorSuite) TestMigratorFromDB() {
+	db, err := sql.Open("postgres", s.dsn)
+	s.Require().NoError(err)
+	defer db.Close()
+
+	// Lock and unlock should use the same session of pool shared with application.
+	db.SetMaxIdleConns(4)
+	migrator, err := core.NewMigratorFromDB(db, "postgres", DefaultTableName, os.Getenv("DIR"))
+	s.Require().NoError(err)
+
+	for i := 0; i < 3; i++ {
+		s.Require().NoError(migrator.Up())
+		s.Require().NoError(migrator.Down())
+	}
+
+	// Pool of application isn't closed by migrator.
+	s.NoError(migrator.Close())
+	s.NoError(db.Ping())
+}
+
+func (s *MigratorSuite) TestUpgradeOldTable() {
+	const table = "old_migrations"
+
+	query := `
+		DROP TABLE IF EXISTS old_migrations;
+		CREATE TABLE old_migrations (id serial PRIMARY KEY, version bigint NOT NULL UNIQUE, applied_at timestamp NOT NULL);
+	`
+	s.Require().NoError(s.driver.Run(strings.NewReader(query)))
+	defer s.driver.Run(strings.NewReader("DROP TABLE IF EXISTS old_migrations;"))
+
+	// Table is upgraded once, the next runs don't change it.
+	for i := 0; i < 2; i++ {
+		migrator, err := core.NewMigrator(s.dsn, table, os.Getenv("DIR"))
+		s.Require().NoError(err)
+		s.Require().NoError(migrator.Close())
+	}
+
+	db, err := sql.Open("postgres", s.dsn)
+	s.Require().NoError(err)
+	defer db.Close()
+
+	var indexes int
+	err = db.QueryRow(
+		`SELECT count(*) FROM pg_indexes WHERE tablename = $1 AND indexdef LIKE '%(name)%'`, table,
+	).Scan(&indexes)
+	s.Require().NoError(err)
+	s.Equal(1, indexes)
+}
+
+func (s *MigratorSuite) TestIntrospectSequences() {
+	query := `
+		DROP SCHEMA IF EXISTS introspect_src CASCADE;
+		DROP SCHEMA IF EXISTS introspect_dst CASCADE;
+		CREATE SCHEMA introspect_src;
+		CREATE SCHEMA introspect_dst;
+		CREATE TABLE introspect_src.serials (id serial PRIMARY KEY, big bigserial, name text);
+		CREATE TABLE introspect_src.identities (
+			id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+			other int GENERATED BY DEFAULT AS IDENTITY
+		);
+		CREATE SEQUENCE introspect_src.numbers START WITH 100 INCREMENT BY 5;
+		CREATE TABLE introspect_src.orders (number bigint DEFAULT nextval('introspect_src.numbers'));
+	`
+	s.Require().NoError(s.driver.Run(strings.NewReader(query)))
+	defer s.driver.Run(strings.NewReader(
+		"DROP SCHEMA IF EXISTS introspect_src CASCADE; DROP SCHEMA IF EXISTS introspect_dst CASCADE;",
+	))
+
+	introspector, ok := s.driver.(database.Introspector)
+	s.Require().True(ok)
+
+	source, err := introspector.Introspect()
+	s.Require().NoError(err)
+	expected := source.Filter("introspect_src")
+	expected.RenameSchema("introspect_src", "introspect_dst")
+
+	s.Require().Len(expected.Sequences, 1)
+	s.Equal("serial", expected.Table("introspect_dst.serials").Column("id").Type)
+	s.Equal("ALWAYS", expected.Table("introspect_dst.identities").Column("id").Identity)
+
+	// Snapshot creates the same schema.
+	var snapshot strings.Builder
+	s.Require().NoError(expected.Dump(&snapshot))
+	s.Require().NoError(s.driver.Run(strings.NewReader(snapshot.String())))
+
+	target, err := introspector.Introspect()
+	s.Require().NoError(err)
+	s.Empty(schema.Compare(expected, target.Filter("introspect_dst")))
+}
+
+// Observer, which blocks the first migration until it is released.
+type blockingObserver struct {
+	core.NopObserver
+	once    sync.Once
+	started chan struct{}
+	release chan struct{}
+}
+
+func (o *blockingObserver) BeforeMigration(core.MigrationEvent) {
+	o.once.Do(func() {
+		close(o.started)
+		<-o.release
+	})
+}
+
+type lockWaitObserver struct {
+	core.NopObserver
+	waits atomic.Int32
+}
+
+func (o *lockWaitObserver) OnLockWait(time.Duration) {
+	o.waits.Add(1)
+}
+
+func (s *MigratorSuite) TestConcurrentMigrators() {
+	dir := os.Getenv("DIR")
+
+	blocking := &blockingObserver{started: make(chan struct{}), release: make(chan struct{})}
+	first, err := core.Open(core.WithDSN(s.dsn), core.WithDir(dir), core.WithHooks(blocking))
+	s.Require().NoError(err)
+	defer first.Close()
+
+	waiting := &lockWaitObserver{}
+	second, err := core.Open(
+		core.WithDSN(s.dsn),
+		core.WithDir(dir),
+		core.WithLockTimeout(300*time.Millisecond),
+		core.WithHooks(waiting),
+	)
+	s.Require().NoError(err)
+	defer second.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- first.Up()
+	}()
+	<-blocking.started
+
+	// The second migrator waits for lock held by the first one and gives up after timeout.
+	s.ErrorIs(second.Up(), database.ErrLocked)
+	s.Positive(waiting.waits.Load())
+
+	close(blocking.release)
+	s.NoError(<-done)
+	s.checkAppliedListCount(3)
+
+	// Lock is released by the first migrator.
+	s.ErrorIs(second.Up(), core.ErrAlreadyUpToDate)
+}
+
 // Check applied list.
 func (s *MigratorSuite) checkAppliedListCount(expectedCount int) {
 	list, err := s.migrator.Status()